@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+
+	"github.com/barbuza/itsalive/pkg/monitor"
+)
+
+func main() {
+	once := flag.Bool("once", false, "run every check once and exit with non-zero status on failures")
+	summaryPath := flag.String("summary", "", "write a JSON summary of -once results to this file (- for stdout)")
+	defaultConfig := os.Getenv("ITSALIVE_CONFIG")
+	if utf8.RuneCountInString(defaultConfig) == 0 {
+		defaultConfig = "itsalive.toml"
+	}
+	configFlag := flag.String("config", defaultConfig, "path to the TOML or YAML config file")
+	flag.Parse()
+
+	var configPath = *configFlag
+
+	switch flag.Arg(0) {
+	case "":
+	case "validate":
+		validateFlags := flag.NewFlagSet("validate", flag.ExitOnError)
+		validatePath := validateFlags.String("config", configPath, "path to the TOML or YAML config file")
+		validateFlags.Parse(flag.Args()[1:])
+		if validateFlags.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "unexpected arguments: %s\n", strings.Join(validateFlags.Args(), " "))
+			os.Exit(2)
+		}
+		if _, err := monitor.LoadConfig(*validatePath); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("%s is valid\n", *validatePath)
+		os.Exit(0)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		os.Exit(2)
+	}
+
+	config, err := monitor.LoadConfig(configPath)
+	if err != nil {
+		panic(err)
+	}
+
+	handler, err := monitor.NewLogHandler(config.LogFormat, config.LogLevel)
+	if err != nil {
+		panic(err)
+	}
+	slog.SetDefault(slog.New(handler))
+
+	if *once {
+		code, err := monitor.RunOnce(config, *summaryPath)
+		if err != nil {
+			panic(err)
+		}
+		os.Exit(code)
+	}
+
+	m, err := monitor.New(config)
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		slog.Info("shutting down", "signal", sig.String())
+		cancel()
+	}()
+
+	reloads := make(chan os.Signal, 1)
+	signal.Notify(reloads, syscall.SIGHUP)
+	go func() {
+		for range reloads {
+			if err := m.Reload(configPath); err != nil {
+				slog.Error("reload failed, keeping current config", "error", err)
+			}
+		}
+	}()
+
+	if err := m.Run(ctx); err != nil {
+		slog.Error("monitor stopped with an error", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,215 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Error("api response failed", "error", err)
+	}
+}
+
+func historyHandler(registry *statusRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		names := registry.names()
+		if name := r.URL.Query().Get("name"); len(name) > 0 {
+			names = []string{name}
+		}
+		sort.Strings(names)
+
+		result := make(map[string][]changeJSON, len(names))
+		for _, name := range names {
+			changes, ok := registry.history(name)
+			if !ok {
+				http.Error(w, "unknown check", http.StatusNotFound)
+				return
+			}
+			items := make([]changeJSON, 0, len(changes))
+			for _, change := range changes {
+				items = append(items, changeToJSON(change))
+			}
+			result[name] = items
+		}
+		writeJSON(w, result)
+	}
+}
+
+func replicasHandler(registry *statusRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		names := registry.names()
+		if name := r.URL.Query().Get("name"); len(name) > 0 {
+			names = []string{name}
+		}
+		sort.Strings(names)
+
+		result := make(map[string][]replicaJSON, len(names))
+		for _, name := range names {
+			replicas, ok := registry.replicas(name)
+			if !ok {
+				http.Error(w, "unknown check", http.StatusNotFound)
+				return
+			}
+			if len(replicas) == 0 {
+				continue
+			}
+			items := make([]replicaJSON, 0, len(replicas))
+			for _, replica := range replicas {
+				items = append(items, replicaToJSON(replica))
+			}
+			result[name] = items
+		}
+		writeJSON(w, result)
+	}
+}
+
+func downtimeHandler(registry *statusRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		records, enabled, err := registry.downtimes(r.URL.Query().Get("name"))
+		if !enabled {
+			http.Error(w, "downtime log is disabled", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if records == nil {
+			records = []downtimeRecord{}
+		}
+		writeJSON(w, records)
+	}
+}
+
+func silenceHandler(registry *statusRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if len(name) == 0 {
+			http.Error(w, "missing name", http.StatusBadRequest)
+			return
+		}
+
+		var until time.Time
+		switch r.Method {
+		case http.MethodPost:
+			duration, err := time.ParseDuration(r.URL.Query().Get("duration"))
+			if err != nil || duration <= 0 {
+				http.Error(w, "invalid duration", http.StatusBadRequest)
+				return
+			}
+			until = time.Now().Add(duration)
+		case http.MethodDelete:
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !registry.silence(name, until) {
+			http.Error(w, "unknown check", http.StatusNotFound)
+			return
+		}
+		if until.IsZero() {
+			slog.Info("unsilenced", "name", name)
+		} else {
+			slog.Info("silenced", "name", name, "until", until)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func heartbeatHandler(registry *statusRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.URL.Path, "/heartbeat/")
+		if len(token) == 0 || !registry.ping(token, time.Now()) {
+			http.Error(w, "unknown heartbeat", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func reloadCheckHandler(m *Monitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := r.URL.Query().Get("name")
+		if len(name) == 0 {
+			http.Error(w, "missing name", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var config CheckConfig
+		if _, err := toml.Decode(string(body), &config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(config.Name) == 0 {
+			config.Name = name
+		}
+		if config.Name != name {
+			http.Error(w, "name mismatch", http.StatusBadRequest)
+			return
+		}
+
+		if err := m.replaceCheck(config); err == errUnknownCheck {
+			http.Error(w, "unknown check", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.Info("reloaded check", "name", name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func serveAPI(ctx context.Context, addr string, registry *statusRegistry, m *Monitor, token string, errs chan<- error) {
+	defer reportPanic(errs)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", statusPageHandler(registry))
+	mux.HandleFunc("/api/status", statusHandler(registry))
+	mux.HandleFunc("/api/sla", slaHandler(registry))
+	mux.HandleFunc("/api/history", historyHandler(registry))
+	mux.HandleFunc("/api/replicas", replicasHandler(registry))
+	mux.HandleFunc("/api/downtime", downtimeHandler(registry))
+	mux.HandleFunc("/heartbeat/", heartbeatHandler(registry))
+	if len(token) > 0 {
+		mux.HandleFunc("/silence", requireToken(token, silenceHandler(registry)))
+		mux.HandleFunc("/reload-check", requireToken(token, reloadCheckHandler(m)))
+		mux.HandleFunc("/api/checks", requireToken(token, checksHandler(m)))
+		mux.HandleFunc("/api/checks/", requireToken(token, checksHandler(m)))
+	} else {
+		mux.HandleFunc("/silence", silenceHandler(registry))
+	}
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	slog.Info("api listening", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		panic(err)
+	}
+}
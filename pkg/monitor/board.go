@@ -0,0 +1,120 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nlopes/slack"
+)
+
+func boardEmoji(status checkStatus) string {
+	switch status {
+	case checkStatusOk:
+		return ":white_check_mark:"
+	case checkStatusAlarm:
+		return ":red_circle:"
+	case checkStatusDegraded:
+		return ":large_yellow_circle:"
+	default:
+		return ":grey_question:"
+	}
+}
+
+func formatStatusBoard(states []checkState) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "*itsalive status* (updated %s)\n", time.Now().Format(time.RFC1123))
+	for _, state := range states {
+		fmt.Fprintf(
+			&text,
+			"%s %s *%s*\n",
+			boardEmoji(state.status),
+			state.name,
+			strings.ToUpper(checkStatusToString(state.status)),
+		)
+	}
+	return text.String()
+}
+
+type slackStatusBoard struct {
+	sync.Mutex
+	api       *slack.Client
+	registry  *statusRegistry
+	elector   *leaderElector
+	channel   string
+	botName   string
+	channelID string
+	timestamp string
+}
+
+func newSlackStatusBoard(
+	ctx context.Context,
+	config NotifierConfig,
+	registry *statusRegistry,
+	elector *leaderElector,
+	errs chan<- error,
+) *slackStatusBoard {
+	board := &slackStatusBoard{
+		api:      slack.New(config.Token),
+		registry: registry,
+		elector:  elector,
+		channel:  config.Channel,
+		botName:  config.BotName,
+	}
+	if err := board.update(); err != nil {
+		slog.Warn("failed to post status board, will retry", "error", err)
+	}
+	if config.RefreshInterval.Duration > 0 {
+		go board.refresh(ctx, config.RefreshInterval.Duration, errs)
+	}
+	return board
+}
+
+func (b *slackStatusBoard) update() error {
+	if !b.elector.isLeader() {
+		return nil
+	}
+	b.Lock()
+	defer b.Unlock()
+	text := formatStatusBoard(b.registry.states())
+	if utf8.RuneCountInString(b.timestamp) > 0 {
+		_, _, _, err := b.api.UpdateMessage(b.channelID, b.timestamp, text)
+		return err
+	}
+
+	params := slack.PostMessageParameters{Username: b.botName}
+	channelID, timestamp, err := b.api.PostMessage(b.channel, text, params)
+	if err != nil {
+		return err
+	}
+	b.channelID, b.timestamp = channelID, timestamp
+	if err := b.api.AddPin(channelID, slack.NewRefToMessage(channelID, timestamp)); err != nil {
+		slog.Warn("failed to pin status board", "error", err)
+	}
+	return nil
+}
+
+func (b *slackStatusBoard) refresh(ctx context.Context, interval time.Duration, errs chan<- error) {
+	defer reportPanic(errs)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := b.update(); err != nil {
+			slog.Warn("failed to update status board", "error", err)
+		}
+	}
+}
+
+func (b *slackStatusBoard) Notify(change statusChange) error {
+	return b.update()
+}
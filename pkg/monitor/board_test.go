@@ -0,0 +1,87 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+type fakeSlack struct {
+	sync.Mutex
+	calls []string
+	reply func(method string) string
+}
+
+func newFakeSlack(t *testing.T) *fakeSlack {
+	fake := &fakeSlack{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := strings.TrimPrefix(r.URL.Path, "/")
+		fake.Lock()
+		fake.calls = append(fake.calls, method)
+		reply := fake.reply
+		fake.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if reply != nil {
+			if body := reply(method); len(body) > 0 {
+				fmt.Fprint(w, body)
+				return
+			}
+		}
+		fmt.Fprint(w, `{"ok": true, "channel": "C1", "ts": "1.0"}`)
+	}))
+	previous := slack.SLACK_API
+	slack.SLACK_API = server.URL + "/"
+	t.Cleanup(func() {
+		slack.SLACK_API = previous
+		server.Close()
+	})
+	return fake
+}
+
+func (f *fakeSlack) methods() []string {
+	f.Lock()
+	defer f.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func TestStatusBoardFollowsLeadership(t *testing.T) {
+	fake := newFakeSlack(t)
+	registry := newStatusRegistry(10)
+	registry.register(CheckConfig{Name: "api"})
+	elector := &leaderElector{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := NotifierConfig{Token: "xoxb", Channel: "#status", BotName: "itsalive", RefreshInterval: Duration{10 * time.Millisecond}}
+	board := newSlackStatusBoard(ctx, config, registry, elector, make(chan error, 1))
+
+	time.Sleep(50 * time.Millisecond)
+	if calls := fake.methods(); len(calls) > 0 {
+		t.Fatalf("a follower called Slack: %v", calls)
+	}
+
+	elector.leader.Store(true)
+	time.Sleep(50 * time.Millisecond)
+	calls := fake.methods()
+	if len(calls) < 3 || calls[0] != "chat.postMessage" || calls[1] != "pins.add" || calls[2] != "chat.update" {
+		t.Fatalf("leader calls = %v, want a post, a pin and updates", calls)
+	}
+
+	elector.leader.Store(false)
+	time.Sleep(20 * time.Millisecond)
+	before := len(fake.methods())
+	if err := board.Notify(statusChange{name: "api"}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if after := len(fake.methods()); after != before {
+		t.Fatalf("a former leader kept editing the board: %d calls, then %d", before, after)
+	}
+}
@@ -0,0 +1,128 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	causeDNS        = "dns"
+	causeRefused    = "connection refused"
+	causeConnection = "connection"
+	causeTimeout    = "timeout"
+	causeTLS        = "tls"
+	causeStatus     = "status"
+	causeRedirect   = "redirect loop"
+	causeClockSkew  = "clock skew"
+	causeBody       = "body"
+	causeSlow       = "slow response"
+	causeUnexpected = "unexpected answer"
+	causeExitCode   = "exit code"
+	causeOutput     = "output"
+)
+
+func classifyError(err error) string {
+	var dnsErr *net.DNSError
+	var verifyErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	switch {
+	case isTimeout(err):
+		return causeTimeout
+	case errors.As(err, &dnsErr):
+		return causeDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return causeRefused
+	case errors.As(err, &verifyErr),
+		errors.As(err, &recordErr),
+		errors.As(err, &alertErr),
+		errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr):
+		return causeTLS
+	default:
+		return causeConnection
+	}
+}
+
+type phaseTiming struct {
+	name  string
+	start time.Time
+	end   time.Time
+}
+
+type requestPhases struct {
+	sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	firstByte    time.Time
+}
+
+func (p *requestPhases) mark(at *time.Time) {
+	p.Lock()
+	defer p.Unlock()
+	*at = time.Now()
+}
+
+func (p *requestPhases) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { p.mark(&p.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { p.mark(&p.dnsDone) },
+		ConnectStart:         func(string, string) { p.mark(&p.connectStart) },
+		ConnectDone:          func(string, string, error) { p.mark(&p.connectDone) },
+		TLSHandshakeStart:    func() { p.mark(&p.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { p.mark(&p.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { p.mark(&p.gotConn) },
+		GotFirstResponseByte: func() { p.mark(&p.firstByte) },
+	}
+}
+
+func (p *requestPhases) stage() string {
+	p.Lock()
+	defer p.Unlock()
+	switch {
+	case !p.firstByte.IsZero():
+		return "response body"
+	case !p.gotConn.IsZero():
+		return "time to first byte"
+	case !p.tlsStart.IsZero():
+		return "TLS handshake"
+	case !p.connectStart.IsZero():
+		return "connect"
+	case !p.dnsStart.IsZero():
+		return "DNS lookup"
+	default:
+		return "connect"
+	}
+}
+
+func (p *requestPhases) timings() []phaseTiming {
+	p.Lock()
+	defer p.Unlock()
+	var timings []phaseTiming
+	for _, phase := range []phaseTiming{
+		{name: "dns", start: p.dnsStart, end: p.dnsDone},
+		{name: "connect", start: p.connectStart, end: p.connectDone},
+		{name: "tls", start: p.tlsStart, end: p.tlsDone},
+		{name: "ttfb", start: p.gotConn, end: p.firstByte},
+	} {
+		if !phase.start.IsZero() && !phase.end.IsZero() {
+			timings = append(timings, phase)
+		}
+	}
+	return timings
+}
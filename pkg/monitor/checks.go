@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type checkJSON struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	URL           string   `json:"url,omitempty"`
+	CheckInterval string   `json:"check_interval"`
+	Tags          []string `json:"tags,omitempty"`
+	Dynamic       bool     `json:"dynamic"`
+}
+
+func (m *Monitor) checks() []checkJSON {
+	m.Lock()
+	defer m.Unlock()
+	items := make([]checkJSON, 0, len(m.config.Items))
+	for _, item := range m.config.Items {
+		items = append(items, checkJSON{
+			Name:          item.Name,
+			Type:          item.Type,
+			URL:           redactURL(item.URL),
+			CheckInterval: item.CheckInterval.String(),
+			Tags:          item.Tags,
+			Dynamic:       m.added[item.Name],
+		})
+	}
+	return items
+}
+
+func (m *Monitor) hasCheck(name string) bool {
+	m.Lock()
+	defer m.Unlock()
+	return containsCheck(m.config.Items, name)
+}
+
+func authorized(r *http.Request, token string) bool {
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+func requireToken(token string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func checksHandler(m *Monitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/checks"), "/")
+		switch {
+		case r.Method == http.MethodGet && len(name) == 0:
+			writeJSON(w, m.checks())
+		case r.Method == http.MethodPost && len(name) == 0:
+			var config CheckConfig
+			if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if m.hasCheck(config.Name) {
+				http.Error(w, fmt.Sprintf("check %q already exists", config.Name), http.StatusConflict)
+				return
+			}
+			if err := m.AddCheck(config); err == errMonitorStopping {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			} else if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			slog.Info("added check", "name", config.Name)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete && len(name) > 0:
+			if !m.RemoveCheck(name) {
+				http.Error(w, "unknown check", http.StatusNotFound)
+				return
+			}
+			slog.Info("removed check", "name", name)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
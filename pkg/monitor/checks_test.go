@@ -0,0 +1,39 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChecksHandlerAdd(t *testing.T) {
+	m, err := New(Config{Items: []CheckConfig{execCheck("api")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := checksHandler(m)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"valid", `{"Name":"db","URL":"http://db.example.com","OKStatuses":[200],"CheckInterval":"1m","HTTPTimeout":"5s","OKPeriods":1,"AlarmPeriods":1}`, http.StatusCreated},
+		{"existing name", `{"Name":"api","URL":"http://api.example.com","CheckInterval":"1m"}`, http.StatusConflict},
+		{"empty name", `{"URL":"http://x","CheckInterval":"1m"}`, http.StatusBadRequest},
+		{"invalid JSON", `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodPost, "/api/checks", strings.NewReader(tt.body)))
+			if rec.Code != tt.status {
+				t.Fatalf("POST /api/checks = %d %q, want %d", rec.Code, rec.Body.String(), tt.status)
+			}
+		})
+	}
+	if m.hasCheck("") {
+		t.Error("added a check without a name")
+	}
+}
@@ -0,0 +1,105 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+const commandHelp = "commands: `status`, `silence <name> <duration>`, `unsilence <name>`, `check <name> now`"
+
+const commandNotLeader = "not the leader, ignoring command"
+
+func handleCommand(text string, registry *statusRegistry, watchers *watcherSet, elector *leaderElector) string {
+	if !elector.isLeader() {
+		return commandNotLeader
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return commandHelp
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "status":
+		return formatStatusBoard(registry.states())
+	case "silence":
+		if len(fields) != 3 {
+			return "usage: `silence <name> <duration>`"
+		}
+		duration, err := time.ParseDuration(fields[2])
+		if err != nil || duration <= 0 {
+			return fmt.Sprintf("invalid duration %q", fields[2])
+		}
+		until := time.Now().Add(duration)
+		if !registry.silence(fields[1], until) {
+			return fmt.Sprintf("unknown check %q", fields[1])
+		}
+		return fmt.Sprintf("silenced %s until %s", fields[1], until.Format(time.RFC1123))
+	case "unsilence":
+		if len(fields) != 2 {
+			return "usage: `unsilence <name>`"
+		}
+		if !registry.silence(fields[1], time.Time{}) {
+			return fmt.Sprintf("unknown check %q", fields[1])
+		}
+		return fmt.Sprintf("unsilenced %s", fields[1])
+	case "check":
+		if len(fields) < 2 {
+			return "usage: `check <name> now`"
+		}
+		if !watchers.checkNow(fields[1]) {
+			return fmt.Sprintf("unknown check %q", fields[1])
+		}
+		return fmt.Sprintf("checking %s", fields[1])
+	default:
+		return commandHelp
+	}
+}
+
+func serveSlackCommands(
+	ctx context.Context,
+	token string,
+	registry *statusRegistry,
+	watchers *watcherSet,
+	elector *leaderElector,
+	errs chan<- error,
+) {
+	defer reportPanic(errs)
+
+	rtm := slack.New(token).NewRTM()
+	go rtm.ManageConnection()
+	defer rtm.Disconnect()
+
+	var mention string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-rtm.IncomingEvents:
+			switch data := event.Data.(type) {
+			case *slack.ConnectedEvent:
+				mention = "<@" + data.Info.User.ID + ">"
+				slog.Info("slack commands connected", "user", data.Info.User.Name)
+			case *slack.InvalidAuthEvent:
+				slog.Error("slack commands: invalid token")
+				return
+			case *slack.MessageEvent:
+				text := strings.TrimSpace(data.Text)
+				if len(mention) == 0 || !strings.HasPrefix(text, mention) {
+					continue
+				}
+				reply := handleCommand(strings.TrimPrefix(text, mention), registry, watchers, elector)
+				if reply == commandNotLeader {
+					slog.Debug(reply, "text", text)
+					continue
+				}
+				rtm.SendMessage(rtm.NewOutgoingMessage(reply, data.Channel))
+			}
+		}
+	}
+}
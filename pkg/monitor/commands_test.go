@@ -0,0 +1,31 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleCommandOnlyOnLeader(t *testing.T) {
+	registry := newStatusRegistry(10)
+	registry.register(CheckConfig{Name: "api"})
+	elector := &leaderElector{}
+
+	if reply := handleCommand("silence api 1h", registry, nil, elector); reply != commandNotLeader {
+		t.Fatalf("follower replied %q, want %q", reply, commandNotLeader)
+	}
+	if registry.silenced("api", time.Now()) {
+		t.Fatal("follower silenced a check")
+	}
+
+	elector.leader.Store(true)
+	if reply := handleCommand("silence api 1h", registry, nil, elector); !strings.HasPrefix(reply, "silenced api") {
+		t.Fatalf("leader replied %q", reply)
+	}
+	if !registry.silenced("api", time.Now()) {
+		t.Fatal("leader did not silence the check")
+	}
+	if reply := handleCommand("unsilence api", registry, nil, nil); reply != "unsilenced api" {
+		t.Fatalf("single node replied %q", reply)
+	}
+}
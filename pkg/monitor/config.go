@@ -0,0 +1,96 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+func expandEnvString(text string, missing map[string]bool) string {
+	return envReference.ReplaceAllStringFunc(text, func(ref string) string {
+		name := envReference.FindStringSubmatch(ref)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing[name] = true
+		}
+		return value
+	})
+}
+
+func expandEnvValue(value reflect.Value, missing map[string]bool) {
+	switch value.Kind() {
+	case reflect.String:
+		if value.CanSet() {
+			value.SetString(expandEnvString(value.String(), missing))
+		}
+	case reflect.Ptr:
+		if !value.IsNil() {
+			expandEnvValue(value.Elem(), missing)
+		}
+	case reflect.Struct:
+		for idx := 0; idx < value.NumField(); idx++ {
+			if value.Type().Field(idx).IsExported() {
+				expandEnvValue(value.Field(idx), missing)
+			}
+		}
+	case reflect.Slice:
+		for idx := 0; idx < value.Len(); idx++ {
+			expandEnvValue(value.Index(idx), missing)
+		}
+	case reflect.Map:
+		if value.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		iter := value.MapRange()
+		for iter.Next() {
+			expanded := reflect.ValueOf(expandEnvString(iter.Value().String(), missing))
+			value.SetMapIndex(iter.Key(), expanded.Convert(value.Type().Elem()))
+		}
+	}
+}
+
+func expandEnv(config *Config) error {
+	missing := make(map[string]bool)
+	expandEnvValue(reflect.ValueOf(config).Elem(), missing)
+	if len(missing) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("undefined environment variables: %s", strings.Join(names, ", "))
+}
+
+func decodeYAML(text string, config *Config) error {
+	var raw interface{}
+	if err := yaml.Unmarshal([]byte(text), &raw); err != nil {
+		return err
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, config)
+}
+
+func decodeConfig(path string, text string, config *Config) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return decodeYAML(text, config)
+	default:
+		_, err := toml.Decode(text, config)
+		return err
+	}
+}
@@ -0,0 +1,208 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("ITSALIVE_TEST_TOKEN", "xoxb-123")
+	t.Setenv("ITSALIVE_TEST_EMPTY", "")
+	t.Setenv("ITSALIVE_TEST_QUOTED", "a\"b\nc")
+
+	verify := true
+	config := Config{
+		APIToken:  "${ITSALIVE_TEST_TOKEN}",
+		Notifiers: []NotifierConfig{{Token: "${ITSALIVE_TEST_TOKEN}", To: []string{"ops+${ITSALIVE_TEST_EMPTY}@example.com"}}},
+		HA:        &HAConfig{RedisPassword: "${ITSALIVE_TEST_QUOTED}"},
+		Items: []CheckConfig{{
+			Name:               "$HOME",
+			BearerToken:        "Bearer ${ITSALIVE_TEST_TOKEN}",
+			Headers:            map[string]string{"X-Token": "${ITSALIVE_TEST_TOKEN}"},
+			InsecureSkipVerify: &verify,
+		}},
+	}
+	if err := expandEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	item := config.Items[0]
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"top level", config.APIToken, "xoxb-123"},
+		{"notifier", config.Notifiers[0].Token, "xoxb-123"},
+		{"empty value", config.Notifiers[0].To[0], "ops+@example.com"},
+		{"quotes and newlines stay in the value", config.HA.RedisPassword, "a\"b\nc"},
+		{"bare dollar", item.Name, "$HOME"},
+		{"check", item.BearerToken, "Bearer xoxb-123"},
+		{"header", item.Headers["X-Token"], "xoxb-123"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestExpandEnvMissing(t *testing.T) {
+	config := Config{
+		APIToken: "${ITSALIVE_TEST_MISSING_B}",
+		Items:    []CheckConfig{{Name: "${ITSALIVE_TEST_MISSING_A}", BearerToken: "${ITSALIVE_TEST_MISSING_B}"}},
+	}
+	err := expandEnv(&config)
+	if err == nil || !strings.Contains(err.Error(), "ITSALIVE_TEST_MISSING_A, ITSALIVE_TEST_MISSING_B") {
+		t.Fatalf("error = %v, want both missing variables listed once", err)
+	}
+}
+
+func TestLoadConfigIgnoresCommentedReferences(t *testing.T) {
+	t.Setenv("ITSALIVE_TEST_TOKEN", "xoxb\"\n123")
+	path := filepath.Join(t.TempDir(), "itsalive.toml")
+	text := `
+# BearerToken = "${ITSALIVE_TEST_UNDEFINED}"
+SlackToken = "${ITSALIVE_TEST_TOKEN}"
+SlackChannel = "#alerts"
+BotName = "itsalive"
+
+[[items]]
+Name = "api"
+URL = "https://example.com/health"
+OKStatuses = [200]
+CheckInterval = "30s"
+OkPeriods = 2
+AlarmPeriods = 2
+HttpTimeout = "5s"
+`
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Notifiers[0].Token; got != "xoxb\"\n123" {
+		t.Fatalf("Token = %q, want the variable's value verbatim", got)
+	}
+	if len(config.Items) != 1 {
+		t.Fatalf("the value changed the config structure: %d items", len(config.Items))
+	}
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	t.Setenv("ITSALIVE_TEST_TOKEN", "xoxb-123")
+	files := map[string]string{
+		"itsalive.toml": `
+SlackToken = "${ITSALIVE_TEST_TOKEN}"
+SlackChannel = "#alerts"
+BotName = "itsalive"
+Jitter = "2s"
+
+[[items]]
+Name = "api"
+URL = "https://example.com/health"
+OKStatuses = [200]
+CheckInterval = "30s"
+OkPeriods = 2
+AlarmPeriods = 2
+HttpTimeout = "5s"
+`,
+		"itsalive.yaml": `
+SlackToken: ${ITSALIVE_TEST_TOKEN}
+SlackChannel: "#alerts"
+BotName: itsalive
+Jitter: 2s
+Items:
+  - Name: api
+    URL: https://example.com/health
+    OKStatuses: [200]
+    CheckInterval: 30s
+    OKPeriods: 2
+    AlarmPeriods: 2
+    HTTPTimeout: 5s
+`,
+	}
+	dir := t.TempDir()
+	for name, text := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+				t.Fatal(err)
+			}
+			config, err := LoadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(config.Notifiers) != 1 || config.Notifiers[0].Token != "xoxb-123" {
+				t.Fatalf("legacy Slack settings were not turned into a notifier: %+v", config.Notifiers)
+			}
+			if len(config.Items) != 1 {
+				t.Fatalf("got %d items, want 1", len(config.Items))
+			}
+			item := config.Items[0]
+			if item.CheckInterval.Duration != 30*time.Second || item.Jitter.Duration != 2*time.Second {
+				t.Errorf("CheckInterval %s, Jitter %s", item.CheckInterval, item.Jitter)
+			}
+			if item.Type != checkTypeHTTP || item.Method != "GET" {
+				t.Errorf("Type %q, Method %q", item.Type, item.Method)
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+		err    string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"empty name", func(c *Config) { c.Items[0].Name = "" }, "Name is empty"},
+		{"duplicate name", func(c *Config) { c.Items = append(c.Items, c.Items[0]) }, `duplicate Name "api"`},
+		{"unknown DependsOn", func(c *Config) { c.Items[0].DependsOn = "db" }, `unknown DependsOn "db"`},
+		{"DependsOn cycle", func(c *Config) {
+			db := validCheckConfig()
+			db.Name = "db"
+			db.DependsOn = "api"
+			c.Items[0].DependsOn = "db"
+			c.Items = append(c.Items, db)
+		}, "DependsOn cycle"},
+		{"heartbeat without APIAddr", func(c *Config) {
+			c.Items[0].Type = checkTypeHeartbeat
+			c.Items[0].Token = "backup"
+			c.Items[0].ExpectedInterval = Duration{time.Hour}
+		}, "heartbeat checks need APIAddr"},
+		{"OutageThreshold over 1", func(c *Config) { c.OutageThreshold = 2 }, "OutageThreshold must be between 0 and 1"},
+		{"OutageThreshold without window", func(c *Config) { c.OutageThreshold = 0.5 }, "OutageWindow == 0s"},
+		{"APIToken without APIAddr", func(c *Config) { c.APIToken = "secret" }, "APIToken without APIAddr"},
+		{"same API and metrics address", func(c *Config) {
+			c.APIAddr = "127.0.0.1:9090"
+			c.MetricsAddr = "127.0.0.1:9090"
+		}, "MetricsAddr and APIAddr must differ"},
+		{"StateOnShutdown without StateFile", func(c *Config) { c.StateOnShutdown = true }, "StateOnShutdown without StateFile"},
+		{"unknown notifier", func(c *Config) { c.Notifiers = []NotifierConfig{{Type: "pager"}} }, `unknown Type "pager"`},
+		{"unknown log level", func(c *Config) { c.LogLevel = "loud" }, "loud"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Items: []CheckConfig{validCheckConfig()}}
+			tt.mutate(&config)
+			setDefaults(&config)
+			err := validateConfig(config)
+			if len(tt.err) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"time"
+)
+
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+type changeDetector struct {
+	name      string
+	persist   int
+	limit     int
+	window    time.Duration
+	known     string
+	candidate string
+	seen      int
+	notified  []time.Time
+}
+
+func newChangeDetector(config CheckConfig) *changeDetector {
+	return &changeDetector{
+		name:    config.Name,
+		persist: max(config.ChangePersistChecks, 1),
+		limit:   config.ChangeLimit,
+		window:  config.ChangeWindow.Duration,
+	}
+}
+
+func (d *changeDetector) observe(hash string, now time.Time) bool {
+	if len(d.known) == 0 {
+		d.known = hash
+		return false
+	}
+	if hash == d.known {
+		d.candidate = ""
+		d.seen = 0
+		return false
+	}
+
+	if hash != d.candidate {
+		d.candidate = hash
+		d.seen = 0
+	}
+	d.seen++
+	if d.seen < d.persist {
+		return false
+	}
+
+	d.known = hash
+	d.candidate = ""
+	d.seen = 0
+
+	if d.limit == 0 {
+		return true
+	}
+	recent := d.notified[:0]
+	for _, at := range d.notified {
+		if now.Sub(at) < d.window {
+			recent = append(recent, at)
+		}
+	}
+	d.notified = recent
+	if len(d.notified) >= d.limit {
+		slog.Info("suppressed content change notification", "name", d.name)
+		return false
+	}
+	d.notified = append(d.notified, now)
+	return true
+}
@@ -0,0 +1,61 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChangeDetectorPersistence(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	detector := newChangeDetector(CheckConfig{Name: "api", ChangePersistChecks: 2})
+
+	steps := []struct {
+		hash string
+		want bool
+	}{
+		{"a", false},
+		{"a", false},
+		{"b", false},
+		{"a", false},
+		{"b", false},
+		{"b", true},
+		{"b", false},
+		{"c", false},
+		{"d", false},
+		{"d", true},
+	}
+	for idx, step := range steps {
+		if got := detector.observe(step.hash, now); got != step.want {
+			t.Fatalf("observe %d (%s) = %v, want %v", idx, step.hash, got, step.want)
+		}
+	}
+}
+
+func TestChangeDetectorRateLimit(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	detector := newChangeDetector(CheckConfig{
+		Name:         "api",
+		ChangeLimit:  2,
+		ChangeWindow: Duration{time.Hour},
+	})
+
+	steps := []struct {
+		hash   string
+		offset time.Duration
+		want   bool
+	}{
+		{"a", 0, false},
+		{"b", time.Minute, true},
+		{"c", 2 * time.Minute, true},
+		{"d", 3 * time.Minute, false},
+		{"e", 4 * time.Minute, false},
+		{"f", 61 * time.Minute, true},
+		{"g", 63 * time.Minute, true},
+		{"h", 64 * time.Minute, false},
+	}
+	for idx, step := range steps {
+		if got := detector.observe(step.hash, start.Add(step.offset)); got != step.want {
+			t.Fatalf("observe %d (%s) = %v, want %v", idx, step.hash, got, step.want)
+		}
+	}
+}
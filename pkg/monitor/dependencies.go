@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+func validateDependencies(items []CheckConfig) error {
+	for idx, item := range items {
+		if err := validateDependency(items, item); err != nil {
+			return fmt.Errorf("invalid item %d: %s", idx, err.Error())
+		}
+	}
+	return nil
+}
+
+func validateDependency(items []CheckConfig, item CheckConfig) error {
+	if utf8.RuneCountInString(item.DependsOn) == 0 {
+		return nil
+	}
+	parents := make(map[string]string, len(items))
+	for _, other := range items {
+		parents[other.Name] = other.DependsOn
+	}
+	parents[item.Name] = item.DependsOn
+	if _, ok := parents[item.DependsOn]; !ok {
+		return fmt.Errorf("unknown DependsOn %q", item.DependsOn)
+	}
+	seen := map[string]bool{item.Name: true}
+	for parent := item.DependsOn; utf8.RuneCountInString(parent) > 0; parent = parents[parent] {
+		if seen[parent] {
+			return fmt.Errorf("DependsOn cycle through %q", parent)
+		}
+		seen[parent] = true
+	}
+	return nil
+}
+
+func (r *statusRegistry) suppressed(name string) bool {
+	r.Lock()
+	defer r.Unlock()
+	return r.suppressedLocked(name)
+}
+
+func (r *statusRegistry) suppressedLocked(name string) bool {
+	state, ok := r.checks[name]
+	if !ok {
+		return false
+	}
+	seen := map[string]bool{name: true}
+	for parent := state.dependsOn; len(parent) > 0 && !seen[parent]; {
+		seen[parent] = true
+		parentState, ok := r.checks[parent]
+		if !ok {
+			return false
+		}
+		if parentState.status == checkStatusAlarm {
+			return true
+		}
+		parent = parentState.dependsOn
+	}
+	return false
+}
+
+func (r *statusRegistry) dependents(name string) ([]string, []string) {
+	r.Lock()
+	defer r.Unlock()
+	var all, failing []string
+	for _, state := range r.checks {
+		if state.dependsOn != name {
+			continue
+		}
+		all = append(all, state.name)
+		if state.status == checkStatusAlarm {
+			failing = append(failing, state.name)
+		}
+	}
+	sort.Strings(all)
+	sort.Strings(failing)
+	return all, failing
+}
+
+func annotateDependents(registry *statusRegistry, change statusChange) statusChange {
+	all, failing := registry.dependents(change.name)
+	if len(all) == 0 {
+		return change
+	}
+	var note string
+	switch change.to {
+	case checkStatusAlarm:
+		note = fmt.Sprintf("suppressing %d dependent checks: %s", len(all), strings.Join(all, ", "))
+	case checkStatusOk:
+		if len(failing) == 0 {
+			return change
+		}
+		note = fmt.Sprintf("dependent checks still failing: %s", strings.Join(failing, ", "))
+	default:
+		return change
+	}
+	if utf8.RuneCountInString(change.detail) > 0 {
+		change.detail += "; " + note
+	} else {
+		change.detail = note
+	}
+	return change
+}
@@ -0,0 +1,164 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	maxDiffLines         = 20
+	maxDiffWindow        = 200
+	diffContext          = 2
+	diffOpEqual          = ' '
+	diffOpDelete         = '-'
+	diffOpInsert         = '+'
+	diffTruncation       = "...\n"
+	diffWindowTruncation = "... diff truncated: %d lines replaced by %d lines, too many to compare\n"
+)
+
+type diffLine struct {
+	op   byte
+	text string
+	a, b int
+}
+
+func commonAffixes(a, b []string) (int, int) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	return prefix, suffix
+}
+
+func diffLines(a, b []string) ([]diffLine, bool) {
+	prefix, suffix := commonAffixes(a, b)
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midA) > maxDiffWindow || len(midB) > maxDiffWindow {
+		return nil, false
+	}
+
+	lines := make([]diffLine, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, diffLine{op: diffOpEqual, text: a[i], a: i, b: i})
+	}
+	lines = append(lines, lcsDiff(midA, midB, prefix, prefix)...)
+	for k := suffix; k > 0; k-- {
+		lines = append(lines, diffLine{op: diffOpEqual, text: a[len(a)-k], a: len(a) - k, b: len(b) - k})
+	}
+	return lines, true
+}
+
+func lcsDiff(a, b []string, offsetA, offsetB int) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{op: diffOpEqual, text: a[i], a: offsetA + i, b: offsetB + j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{op: diffOpDelete, text: a[i], a: offsetA + i, b: offsetB + j})
+			i++
+		default:
+			lines = append(lines, diffLine{op: diffOpInsert, text: b[j], a: offsetA + i, b: offsetB + j})
+			j++
+		}
+	}
+	return lines
+}
+
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func writeDiffLine(out *strings.Builder, op byte, text string) {
+	out.WriteByte(op)
+	out.WriteString(text)
+	if !strings.HasSuffix(text, "\n") {
+		out.WriteString("\n")
+	}
+}
+
+func truncatedDiff(a, b []string) string {
+	prefix, suffix := commonAffixes(a, b)
+	start := max(prefix-diffContext, 0)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "@@ -%d +%d @@\n", start+1, start+1)
+	for _, line := range a[start:prefix] {
+		writeDiffLine(&out, diffOpEqual, line)
+	}
+	fmt.Fprintf(&out, diffWindowTruncation, len(a)-prefix-suffix, len(b)-prefix-suffix)
+	return out.String()
+}
+
+func unifiedDiff(a, b string, maxLines int) string {
+	linesA, linesB := splitLines(a), splitLines(b)
+	lines, ok := diffLines(linesA, linesB)
+	if !ok {
+		return truncatedDiff(linesA, linesB)
+	}
+
+	var out strings.Builder
+	written := 0
+	for idx := 0; idx < len(lines); {
+		if lines[idx].op == diffOpEqual {
+			idx++
+			continue
+		}
+
+		start := max(idx-diffContext, 0)
+		end := idx
+		for end < len(lines) {
+			if lines[end].op != diffOpEqual {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && next-end < 2*diffContext && lines[next].op == diffOpEqual {
+				next++
+			}
+			if next < len(lines) && lines[next].op != diffOpEqual {
+				end = next
+				continue
+			}
+			end = min(end+diffContext, len(lines))
+			break
+		}
+
+		fmt.Fprintf(&out, "@@ -%d +%d @@\n", lines[start].a+1, lines[start].b+1)
+		for _, line := range lines[start:end] {
+			if written == maxLines {
+				out.WriteString(diffTruncation)
+				return out.String()
+			}
+			writeDiffLine(&out, line.op, line.text)
+			written++
+		}
+		idx = end
+	}
+	return out.String()
+}
@@ -0,0 +1,73 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func numberedLines(from, to int) string {
+	var text strings.Builder
+	for i := from; i <= to; i++ {
+		fmt.Fprintf(&text, "line %d\n", i)
+	}
+	return text.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a\nb\nc\n", "a\nb\nc\n", ""},
+		{"both empty", "", "", ""},
+		{"changed line", "a\nb\nc\nd\ne\nf\n", "a\nb\nc\nX\ne\nf\n", "@@ -2 +2 @@\n b\n c\n-d\n+X\n e\n f\n"},
+		{"appended line", "a\nb\n", "a\nb\nc\n", "@@ -1 +1 @@\n a\n b\n+c\n"},
+		{"missing newline", "a", "b", "@@ -1 +1 @@\n-a\n+b\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff(tt.a, tt.b, maxDiffLines); got != tt.want {
+				t.Fatalf("unifiedDiff =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffMaxLines(t *testing.T) {
+	got := unifiedDiff("a\nb\nc\nd\n", "w\nx\ny\nz\n", 3)
+	want := "@@ -1 +1 @@\n-a\n-b\n-c\n" + diffTruncation
+	if got != want {
+		t.Fatalf("unifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffEqualLargeBody(t *testing.T) {
+	body := numberedLines(1, 5000)
+	if got := unifiedDiff(body, body, maxDiffLines); got != "" {
+		t.Fatalf("equal bodies produced a diff:\n%s", got)
+	}
+}
+
+func TestUnifiedDiffShiftedLargeBody(t *testing.T) {
+	a := numberedLines(1, 5000)
+	b := "header\n" + a
+	got := unifiedDiff(a, b, maxDiffLines)
+	if got != "@@ -1 +1 @@\n+header\n line 1\n line 2\n" {
+		t.Fatalf("one inserted line was reported as\n%s", got)
+	}
+}
+
+func TestUnifiedDiffTruncatedBody(t *testing.T) {
+	a := "head 1\nhead 2\nhead 3\n" + numberedLines(1, 500) + "tail\n"
+	b := "head 1\nhead 2\nhead 3\n" + numberedLines(1001, 1700) + "tail\n"
+	got := unifiedDiff(a, b, maxDiffLines)
+	want := "@@ -2 +2 @@\n head 2\n head 3\n" + fmt.Sprintf(diffWindowTruncation, 500, 700)
+	if got != want {
+		t.Fatalf("unifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(got, "\n+") || strings.Contains(got, "\n-") {
+		t.Fatalf("diffed across the window cut:\n%s", got)
+	}
+}
@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var dnsRecordTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT"}
+
+func isKnownRecordType(recordType string) bool {
+	for _, known := range dnsRecordTypes {
+		if recordType == known {
+			return true
+		}
+	}
+	return false
+}
+
+func newResolver(config CheckConfig) *net.Resolver {
+	if utf8.RuneCountInString(config.Resolver) == 0 {
+		return net.DefaultResolver
+	}
+	dialer := &net.Dialer{Timeout: config.HTTPTimeout.Duration}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, config.Resolver)
+		},
+	}
+}
+
+func normalizeRecord(value string) string {
+	return strings.TrimSuffix(strings.ToLower(value), ".")
+}
+
+func lookupRecords(ctx context.Context, resolver *net.Resolver, recordType string, host string) ([]string, error) {
+	var values []string
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			values = append(values, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, cname)
+	case "MX":
+		records, err := resolver.LookupMX(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			values = append(values, record.Host)
+		}
+	case "NS":
+		records, err := resolver.LookupNS(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			values = append(values, record.Host)
+		}
+	case "TXT":
+		records, err := resolver.LookupTXT(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		values = records
+	default:
+		return nil, fmt.Errorf("unknown RecordType %q", recordType)
+	}
+	for idx := range values {
+		values[idx] = normalizeRecord(values[idx])
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+func probeDNS(ctx context.Context, resolver *net.Resolver, config CheckConfig) probeResult {
+	ctx, cancel := context.WithTimeout(ctx, config.HTTPTimeout.Duration)
+	defer cancel()
+
+	values, err := lookupRecords(ctx, resolver, config.RecordType, config.URL)
+	if err != nil {
+		return probeResult{detail: err.Error(), timeout: isTimeout(err), cause: classifyError(err)}
+	}
+
+	found := make(map[string]bool, len(values))
+	for _, value := range values {
+		found[value] = true
+	}
+	for _, expected := range config.Expect {
+		if !found[normalizeRecord(expected)] {
+			return probeResult{detail: fmt.Sprintf(
+				"%s has no %s record %s, got [%s]",
+				config.URL,
+				config.RecordType,
+				expected,
+				strings.Join(values, ", "),
+			), cause: causeUnexpected}
+		}
+	}
+	return probeResult{ok: true, detail: strings.Join(values, ", ")}
+}
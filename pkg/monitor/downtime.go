@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+type downtimeRecord struct {
+	Name    string    `json:"name"`
+	URL     string    `json:"url"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Seconds float64   `json:"seconds"`
+}
+
+func newDowntimeRecord(change statusChange) downtimeRecord {
+	return downtimeRecord{
+		Name:    change.name,
+		URL:     change.url,
+		Start:   change.time.Add(-change.downtime),
+		End:     change.time,
+		Seconds: change.downtime.Seconds(),
+	}
+}
+
+func appendDowntime(path string, record downtimeRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func readDowntime(path string, name string) ([]downtimeRecord, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []downtimeRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record downtimeRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, err
+		}
+		if len(name) == 0 || record.Name == name {
+			records = append(records, record)
+		}
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,52 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type familyResult struct {
+	result  probeResult
+	elapsed time.Duration
+}
+
+func probeFamily(ctx context.Context, client *http.Client, config CheckConfig, out *familyResult, wg *sync.WaitGroup) {
+	defer wg.Done()
+	start := time.Now()
+	out.result = probeHTTP(ctx, client, config)
+	out.elapsed = time.Since(start)
+}
+
+func probeDualStack(ctx context.Context, client4, client6 *http.Client, config CheckConfig) probeResult {
+	var ipv4, ipv6 familyResult
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go probeFamily(ctx, client4, config, &ipv4, &wg)
+	go probeFamily(ctx, client6, config, &ipv6, &wg)
+	wg.Wait()
+
+	switch {
+	case !ipv4.result.ok && !ipv6.result.ok:
+		return probeResult{detail: "both IPv4 and IPv6 failing", timeout: ipv4.result.timeout && ipv6.result.timeout}
+	case !ipv6.result.ok:
+		return probeResult{detail: "IPv6 failing while IPv4 ok", timeout: ipv6.result.timeout}
+	case !ipv4.result.ok:
+		return probeResult{detail: "IPv4 failing while IPv6 ok", timeout: ipv4.result.timeout}
+	}
+
+	if config.MaxFamilyLatencyGap.Duration > 0 {
+		gap := ipv6.elapsed - ipv4.elapsed
+		slower, faster := "IPv6", "IPv4"
+		if gap < 0 {
+			gap = -gap
+			slower, faster = "IPv4", "IPv6"
+		}
+		if gap > config.MaxFamilyLatencyGap.Duration {
+			return probeResult{detail: fmt.Sprintf("%s slower than %s by %s", slower, faster, gap.Truncate(time.Millisecond))}
+		}
+	}
+	return probeResult{ok: true}
+}
@@ -0,0 +1,60 @@
+package monitor
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"unicode/utf8"
+)
+
+type emailNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+func newEmailNotifier(config NotifierConfig) *emailNotifier {
+	n := &emailNotifier{
+		addr: config.SMTPAddr,
+		from: config.From,
+		to:   config.To,
+	}
+	if utf8.RuneCountInString(config.Username) > 0 {
+		host, _, _ := net.SplitHostPort(config.SMTPAddr)
+		n.auth = smtp.PlainAuth("", config.Username, config.Password, host)
+	}
+	return n
+}
+
+func formatEmail(from string, to []string, change statusChange) []byte {
+	status := strings.ToUpper(checkStatusToString(change.to))
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: [itsalive] %s is %s\r\n", change.name, status)
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s changed from %s to %s at %s\r\n",
+		change.name,
+		checkStatusToString(change.from),
+		checkStatusToString(change.to),
+		change.time.Format("2006-01-02 15:04:05 MST"),
+	)
+	if utf8.RuneCountInString(change.url) > 0 {
+		fmt.Fprintf(&msg, "URL: %s\r\n", change.url)
+	}
+	if utf8.RuneCountInString(change.severity) > 0 {
+		fmt.Fprintf(&msg, "Severity: %s\r\n", change.severity)
+	}
+	if utf8.RuneCountInString(change.detail) > 0 {
+		fmt.Fprintf(&msg, "\r\n%s\r\n", strings.Replace(change.detail, "\n", "\r\n", -1))
+	}
+	return msg.Bytes()
+}
+
+func (n *emailNotifier) Notify(change statusChange) error {
+	return smtp.SendMail(n.addr, n.auth, n.from, n.to, formatEmail(n.from, n.to, change))
+}
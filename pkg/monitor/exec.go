@@ -0,0 +1,91 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxCommandOutput = 1024
+	maxCommandStdout = 64 << 10
+	commandWaitDelay = time.Second
+)
+
+type cappedBuffer struct {
+	bytes.Buffer
+	limit int
+	full  bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	written := len(p)
+	if b.full {
+		return written, nil
+	}
+	if room := b.limit - b.Len(); len(p) > room {
+		for room > 0 && !utf8.RuneStart(p[room]) {
+			room--
+		}
+		p = p[:room]
+		b.full = true
+	}
+	b.Buffer.Write(p)
+	return written, nil
+}
+
+func truncateOutput(output string, limit int) string {
+	if len(output) <= limit {
+		return output
+	}
+	for limit > 0 && !utf8.RuneStart(output[limit]) {
+		limit--
+	}
+	return output[:limit]
+}
+
+func runCommand(ctx context.Context, config CheckConfig) probeResult {
+	ctx, cancel := context.WithTimeout(ctx, config.HTTPTimeout.Duration)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, config.Command, config.Args...)
+	cmd.Env = append(
+		os.Environ(),
+		"ITSALIVE_NAME="+config.Name,
+		"ITSALIVE_URL="+config.URL,
+		"ITSALIVE_TIMEOUT="+config.HTTPTimeout.String(),
+	)
+
+	cmd.WaitDelay = commandWaitDelay
+
+	stdout := &cappedBuffer{limit: maxCommandStdout}
+	stderr := &cappedBuffer{limit: maxCommandOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+
+	output := strings.TrimSpace(truncateOutput(stdout.String(), maxCommandOutput))
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return probeResult{detail: "command timed out", timeout: true, cause: causeTimeout}
+	}
+	if err != nil {
+		detail := output
+		if len(detail) == 0 {
+			detail = err.Error()
+		}
+		if errOutput := strings.TrimSpace(stderr.String()); len(errOutput) > 0 {
+			detail += "\nstderr: " + errOutput
+		}
+		return probeResult{detail: detail, cause: causeExitCode}
+	}
+	if config.outputMatch != nil && !config.outputMatch.MatchString(strings.TrimSpace(stdout.String())) {
+		return probeResult{detail: "output does not match " + config.OutputMatch + ": " + output, cause: causeOutput}
+	}
+	return probeResult{ok: true, detail: output}
+}
@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func execConfig(timeout time.Duration, script string) CheckConfig {
+	return CheckConfig{
+		Name:        "script",
+		Type:        checkTypeExec,
+		Command:     "sh",
+		Args:        []string{"-c", script},
+		HTTPTimeout: Duration{timeout},
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		match  string
+		ok     bool
+		cause  string
+		detail string
+	}{
+		{"success", "echo healthy", "", true, "", "healthy"},
+		{"exit code", "echo broken; exit 3", "", false, causeExitCode, "broken"},
+		{"stderr", "echo 'disk full' >&2; exit 1", "", false, causeExitCode, "exit status 1\nstderr: disk full"},
+		{"environment", `echo "$ITSALIVE_NAME"`, "", true, "", "script"},
+		{"output match", "echo 'queue=3'", `^queue=\d$`, true, "", "queue=3"},
+		{"output mismatch", "echo 'queue=30'", `^queue=\d$`, false, causeOutput, `output does not match ^queue=\d$: queue=30`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := execConfig(5*time.Second, tt.script)
+			if len(tt.match) > 0 {
+				config.OutputMatch = tt.match
+				config.outputMatch = regexp.MustCompile(tt.match)
+			}
+			result := runCommand(context.Background(), config)
+			if result.ok != tt.ok || result.cause != tt.cause || result.detail != tt.detail {
+				t.Fatalf("got ok %v, cause %q, detail %q; want %v, %q, %q",
+					result.ok, result.cause, result.detail, tt.ok, tt.cause, tt.detail)
+			}
+		})
+	}
+}
+
+func TestRunCommandTimeoutWithOrphan(t *testing.T) {
+	start := time.Now()
+	result := runCommand(context.Background(), execConfig(100*time.Millisecond, "sleep 30 & sleep 30"))
+	if !result.timeout || result.cause != causeTimeout {
+		t.Fatalf("got %+v, want a timeout", result)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("a child holding stdout kept the check running for %s", elapsed)
+	}
+}
+
+func TestRunCommandLimitsOutput(t *testing.T) {
+	result := runCommand(context.Background(), execConfig(5*time.Second, "yes é | head -c 1000000"))
+	if !result.ok {
+		t.Fatalf("got %+v", result)
+	}
+	if len(result.detail) > maxCommandOutput || !utf8.ValidString(result.detail) {
+		t.Fatalf("detail is %d bytes, valid UTF-8 %v", len(result.detail), utf8.ValidString(result.detail))
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	buffer := &cappedBuffer{limit: 8}
+	for _, chunk := range []string{"abc", "déf", "éa", "b"} {
+		if n, err := buffer.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if got := buffer.String(); got != "abcdéf" {
+		t.Fatalf("buffer = %q, want %q", got, "abcdéf")
+	}
+
+	if got := truncateOutput(strings.Repeat("é", 10), 5); got != "éé" {
+		t.Fatalf("truncateOutput = %q, want %q", got, "éé")
+	}
+}
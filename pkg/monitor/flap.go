@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"time"
+)
+
+const (
+	flapNone = iota
+	flapStarted
+	flapSuppressed
+)
+
+type flapDetector struct {
+	threshold   int
+	window      time.Duration
+	transitions []time.Time
+	flapping    bool
+	suppressed  int
+}
+
+func newFlapDetector(config CheckConfig) *flapDetector {
+	return &flapDetector{
+		threshold: config.FlapThreshold,
+		window:    config.FlapWindow.Duration,
+	}
+}
+
+func (d *flapDetector) prune(now time.Time) {
+	recent := d.transitions[:0]
+	for _, at := range d.transitions {
+		if now.Sub(at) < d.window {
+			recent = append(recent, at)
+		}
+	}
+	d.transitions = recent
+}
+
+func (d *flapDetector) transition(now time.Time) int {
+	if d.threshold == 0 {
+		return flapNone
+	}
+	d.prune(now)
+	d.transitions = append(d.transitions, now)
+	if d.flapping {
+		d.suppressed++
+		return flapSuppressed
+	}
+	if len(d.transitions) > d.threshold {
+		d.flapping = true
+		d.suppressed = 0
+		return flapStarted
+	}
+	return flapNone
+}
+
+func (d *flapDetector) stabilized(now time.Time) (int, bool) {
+	if !d.flapping {
+		return 0, false
+	}
+	d.prune(now)
+	if len(d.transitions) > 0 {
+		return 0, false
+	}
+	d.flapping = false
+	return d.suppressed, true
+}
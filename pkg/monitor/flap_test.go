@@ -0,0 +1,68 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlapDetector(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	detector := newFlapDetector(CheckConfig{FlapThreshold: 3, FlapWindow: Duration{10 * time.Minute}})
+
+	steps := []struct {
+		offset time.Duration
+		want   int
+	}{
+		{0, flapNone},
+		{time.Minute, flapNone},
+		{2 * time.Minute, flapNone},
+		{3 * time.Minute, flapStarted},
+		{4 * time.Minute, flapSuppressed},
+		{5 * time.Minute, flapSuppressed},
+	}
+	for idx, step := range steps {
+		if got := detector.transition(start.Add(step.offset)); got != step.want {
+			t.Fatalf("transition %d = %d, want %d", idx, got, step.want)
+		}
+	}
+
+	if _, ok := detector.stabilized(start.Add(10 * time.Minute)); ok {
+		t.Fatal("stabilized while transitions are still inside the window")
+	}
+	suppressed, ok := detector.stabilized(start.Add(15 * time.Minute))
+	if !ok {
+		t.Fatal("did not stabilize once the window was clear")
+	}
+	if suppressed != 2 {
+		t.Fatalf("suppressed = %d, want 2", suppressed)
+	}
+	if _, ok := detector.stabilized(start.Add(16 * time.Minute)); ok {
+		t.Fatal("reported stabilization twice")
+	}
+	if got := detector.transition(start.Add(20 * time.Minute)); got != flapNone {
+		t.Fatalf("transition after stabilizing = %d, want %d", got, flapNone)
+	}
+}
+
+func TestFlapDetectorSpreadOut(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	detector := newFlapDetector(CheckConfig{FlapThreshold: 2, FlapWindow: Duration{time.Minute}})
+	for idx := 0; idx < 10; idx++ {
+		if got := detector.transition(start.Add(time.Duration(idx) * 40 * time.Second)); got != flapNone {
+			t.Fatalf("transition %d = %d, want %d", idx, got, flapNone)
+		}
+	}
+}
+
+func TestFlapDetectorDisabled(t *testing.T) {
+	detector := newFlapDetector(CheckConfig{})
+	now := time.Now()
+	for idx := 0; idx < 100; idx++ {
+		if got := detector.transition(now); got != flapNone {
+			t.Fatalf("transition %d = %d, want %d", idx, got, flapNone)
+		}
+	}
+	if _, ok := detector.stabilized(now); ok {
+		t.Fatal("disabled detector reported stabilization")
+	}
+}
@@ -0,0 +1,47 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func grpcCredentials(config CheckConfig) credentials.TransportCredentials {
+	if !config.UseTLS {
+		return insecure.NewCredentials()
+	}
+	if config.tlsConfig != nil {
+		return credentials.NewTLS(config.tlsConfig.Clone())
+	}
+	return credentials.NewTLS(&tls.Config{})
+}
+
+func probeGRPC(ctx context.Context, config CheckConfig) probeResult {
+	conn, err := grpc.NewClient(config.URL, grpc.WithTransportCredentials(grpcCredentials(config)))
+	if err != nil {
+		return probeResult{detail: err.Error()}
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, config.HTTPTimeout.Duration)
+	defer cancel()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: config.Service})
+	if err != nil {
+		if status.Code(err) == codes.DeadlineExceeded {
+			return probeResult{detail: err.Error(), timeout: true, cause: causeTimeout}
+		}
+		return probeResult{detail: err.Error(), cause: causeConnection}
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return probeResult{detail: fmt.Sprintf("health status %s", resp.GetStatus()), cause: causeStatus}
+	}
+	return probeResult{ok: true}
+}
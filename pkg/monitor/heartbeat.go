@@ -0,0 +1,26 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+)
+
+func probeHeartbeat(registry *statusRegistry, config CheckConfig) probeResult {
+	if registry == nil {
+		return probeResult{detail: "heartbeat checks are passive"}
+	}
+	last, since, ok := registry.lastPing(config.Name)
+	if !ok {
+		return probeResult{detail: "unknown check"}
+	}
+	if last.IsZero() {
+		if time.Since(since) > config.ExpectedInterval.Duration {
+			return probeResult{detail: fmt.Sprintf("no heartbeat since startup %s ago", time.Since(since).Truncate(time.Second))}
+		}
+		return probeResult{ok: true}
+	}
+	if age := time.Since(last); age > config.ExpectedInterval.Duration {
+		return probeResult{detail: fmt.Sprintf("last heartbeat %s ago, expected every %s", age.Truncate(time.Second), config.ExpectedInterval)}
+	}
+	return probeResult{ok: true}
+}
@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+var icmpSequence atomic.Uint32
+
+func probeICMP(ctx context.Context, config CheckConfig) probeResult {
+	ctx, cancel := context.WithTimeout(ctx, config.HTTPTimeout.Duration)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, config.URL)
+	if err != nil {
+		return probeResult{detail: err.Error(), timeout: isTimeout(err), cause: classifyError(err)}
+	}
+	if len(addrs) == 0 {
+		return probeResult{detail: fmt.Sprintf("no addresses for %s", config.URL), cause: causeDNS}
+	}
+	target := addrs[0]
+
+	network, raw, listen, proto := "udp6", "ip6:ipv6-icmp", "::", 58
+	var echo, reply icmp.Type = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	if target.IP.To4() != nil {
+		network, raw, listen, proto = "udp4", "ip4:icmp", "0.0.0.0", 1
+		echo, reply = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	}
+	if utf8.RuneCountInString(config.SourceIP) > 0 {
+		listen = config.SourceIP
+	}
+
+	var dst net.Addr = &net.UDPAddr{IP: target.IP, Zone: target.Zone}
+	conn, err := icmp.ListenPacket(network, listen)
+	if err != nil {
+		conn, err = icmp.ListenPacket(raw, listen)
+		if err != nil {
+			return probeResult{detail: err.Error()}
+		}
+		dst = &net.IPAddr{IP: target.IP, Zone: target.Zone}
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	seq := int(icmpSequence.Add(1) & 0xffff)
+	payload := []byte("itsalive " + config.Name)
+	msg := icmp.Message{
+		Type: echo,
+		Body: &icmp.Echo{ID: seq, Seq: seq, Data: payload},
+	}
+	packet, err := msg.Marshal(nil)
+	if err != nil {
+		return probeResult{detail: err.Error()}
+	}
+	if _, err := conn.WriteTo(packet, dst); err != nil {
+		return probeResult{detail: err.Error(), timeout: isTimeout(err)}
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if isTimeout(err) {
+				return probeResult{detail: "ping timed out", timeout: true, cause: causeTimeout}
+			}
+			return probeResult{detail: err.Error()}
+		}
+		parsed, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil || parsed.Type != reply {
+			continue
+		}
+		body, ok := parsed.Body.(*icmp.Echo)
+		if ok && body.Seq == seq && bytes.Equal(body.Data, payload) {
+			return probeResult{ok: true}
+		}
+	}
+}
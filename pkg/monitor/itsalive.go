@@ -0,0 +1,1495 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type Duration struct {
+	time.Duration
+}
+
+type checkStatus int
+
+const (
+	checkStatusUnknown  checkStatus = iota
+	checkStatusOk                   = iota
+	checkStatusAlarm                = iota
+	checkStatusDegraded             = iota
+)
+
+const (
+	defaultBodyMaxBytes = 1 << 20
+	maxDrainBytes       = 64 << 10
+)
+
+const (
+	severityWarning  = "warning"
+	severityCritical = "critical"
+)
+
+const (
+	checkTypeHTTP      = "http"
+	checkTypeExec      = "exec"
+	checkTypeDualStack = "dualstack"
+	checkTypeTCP       = "tcp"
+	checkTypeICMP      = "icmp"
+	checkTypeTLS       = "tls"
+	checkTypeDNS       = "dns"
+	checkTypeHeartbeat = "heartbeat"
+	checkTypeGRPC      = "grpc"
+)
+
+type EscalationStep struct {
+	Streak   int
+	Severity string
+}
+
+type JSONThreshold struct {
+	Path  string
+	Op    string
+	Value float64
+}
+
+type CheckConfig struct {
+	Name                string
+	DependsOn           string
+	Type                string
+	Invert              bool
+	URL                 string
+	URLs                []string
+	Proxies             []string
+	Quorum              int
+	Method              string
+	Headers             map[string]string
+	BasicAuthUser       string
+	BasicAuthPassword   string
+	BearerToken         string
+	Command             string
+	Args                []string
+	OutputMatch         string
+	Resolver            string
+	RecordType          string
+	Expect              []string
+	Token               string
+	ExpectedInterval    Duration
+	Service             string
+	UseTLS              bool
+	Body                string
+	Tags                []string
+	SlackChannel        string
+	SlackBotName        string
+	SlackIconEmoji      string
+	OKStatuses          []int
+	CheckInterval       Duration
+	Jitter              Duration
+	OKPeriods           int
+	AlarmPeriods        int
+	HTTPTimeout         Duration
+	MaxResponseTime     Duration
+	MaxLatency          Duration
+	TimeoutPeriods      int
+	TimeoutSeverity     string
+	Escalation          []EscalationStep
+	RenotifyInterval    Duration
+	EscalateAfter       int
+	EscalationChannel   string
+	EscalationTags      []string
+	NeverHealthyAfter   Duration
+	SourceIP            string
+	FollowRedirects     bool
+	MaxRedirects        int
+	ProxyURL            string
+	InsecureSkipVerify  *bool
+	CAFile              string
+	ClientCert          string
+	ClientKey           string
+	JSONThresholds      []JSONThreshold
+	BodyContains        string
+	BodyMatch           string
+	BodyMaxBytes        int
+	GoldenFile          string
+	DetectChanges       bool
+	ChangePersistChecks int
+	ChangeLimit         int
+	ChangeWindow        Duration
+	FlapThreshold       int
+	FlapWindow          Duration
+	MessageTemplate     string
+	MaintenanceWindows  []MaintenanceWindow
+	MaxClockSkew        Duration
+	CertExpiryWarning   Duration
+	MaxFamilyLatencyGap Duration
+	RequireOCSPStapling bool
+
+	golden          string
+	bodyTemplate    *template.Template
+	bodyMatch       *regexp.Regexp
+	outputMatch     *regexp.Regexp
+	messageTemplate *template.Template
+	tlsConfig       *tls.Config
+	notifyOnStart   bool
+}
+
+type probeResult struct {
+	ok         bool
+	detail     string
+	timeout    bool
+	statusCode int
+	bodyHash   string
+	replicas   []replicaResult
+	certExpiry time.Time
+	cause      string
+	phases     []phaseTiming
+}
+
+type redirectLoopError struct {
+	chain []string
+}
+
+type Config struct {
+	Items               []CheckConfig
+	SlackToken          string
+	SlackChannel        string
+	BotName             string
+	MessageTemplate     string
+	CommandToken        string
+	Notifiers           []NotifierConfig
+	Routes              []RouteConfig
+	Digest              *DigestConfig
+	HA                  *HAConfig
+	OutageThreshold     float64
+	OutageWindow        Duration
+	APIAddr             string
+	APIToken            string
+	MetricsAddr         string
+	HistorySize         int
+	LogFormat           string
+	LogLevel            string
+	OTLPEndpoint        string
+	OTLPInsecure        bool
+	StartupRate         int
+	MaxConcurrentChecks int
+	Jitter              Duration
+	FlapThreshold       int
+	FlapWindow          Duration
+	NotifyOnStart       bool
+	BatchWindow         Duration
+	MaintenanceWindows  []MaintenanceWindow
+	StateFile           string
+	StateOnShutdown     bool
+	DowntimeLog         string
+	ProxyURL            string
+	InsecureSkipVerify  bool
+	CAFile              string
+	ClientCert          string
+	ClientKey           string
+	FollowRedirects     bool
+	MaxRedirects        int
+
+	prepared bool
+}
+
+type statusChange struct {
+	name       string
+	url        string
+	time       time.Time
+	from       checkStatus
+	to         checkStatus
+	detail     string
+	source     string
+	severity   string
+	tags       []string
+	elapsed    time.Duration
+	statusCode int
+	cause      string
+	message    *template.Template
+	certExpiry time.Time
+	channel    string
+	botName    string
+	iconEmoji  string
+	downtime   time.Duration
+	flapping   bool
+}
+
+func reportPanic(errs chan<- error) {
+	if r := recover(); r != nil {
+		err, ok := r.(error)
+		if !ok {
+			err = fmt.Errorf("%v", r)
+		}
+		select {
+		case errs <- err:
+		default:
+			slog.Error("unhandled error", "error", err)
+		}
+	}
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	var err error
+	d.Duration, err = time.ParseDuration(string(text))
+	return err
+}
+
+func (e *redirectLoopError) Error() string {
+	return "redirect loop: " + strings.Join(e.chain, " -> ")
+}
+
+func ignoreRedirect(req *http.Request, via []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+func limitRedirects(maxRedirects int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) < maxRedirects {
+			return nil
+		}
+		chain := make([]string, 0, len(via)+1)
+		for _, prev := range via {
+			chain = append(chain, prev.URL.Redacted())
+		}
+		chain = append(chain, req.URL.Redacted())
+		return &redirectLoopError{chain: chain}
+	}
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func checkResponse(resp *http.Response, err error, config CheckConfig) probeResult {
+	if err != nil {
+		var loop *redirectLoopError
+		if errors.As(err, &loop) {
+			return probeResult{detail: loop.Error(), cause: causeRedirect}
+		}
+		if isTimeout(err) {
+			return probeResult{detail: "timeout", timeout: true, cause: causeTimeout}
+		}
+		return probeResult{detail: err.Error(), cause: classifyError(err)}
+	}
+	defer drainBody(resp.Body)
+	if !intInSlice(resp.StatusCode, config.OKStatuses) {
+		return probeResult{detail: fmt.Sprintf("unexpected status %d", resp.StatusCode), cause: causeStatus}
+	}
+	if config.MaxClockSkew.Duration > 0 {
+		if result, ok := checkClockSkew(resp, config.MaxClockSkew.Duration); !ok {
+			return result
+		}
+	}
+	if config.RequireOCSPStapling {
+		if err := checkOCSPStapling(resp); err != nil {
+			return probeResult{detail: err.Error(), cause: causeTLS}
+		}
+	}
+	if !needsBody(config) {
+		return probeResult{ok: true}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(config.BodyMaxBytes)))
+	if err != nil {
+		return probeResult{detail: err.Error(), cause: classifyError(err), timeout: isTimeout(err)}
+	}
+	var bodyHash string
+	if config.DetectChanges {
+		bodyHash = hashBody(body)
+	}
+	if utf8.RuneCountInString(config.BodyContains) > 0 && !bytes.Contains(body, []byte(config.BodyContains)) {
+		return probeResult{detail: fmt.Sprintf("body does not contain %q", config.BodyContains), cause: causeBody}
+	}
+	if config.bodyMatch != nil && !config.bodyMatch.Match(body) {
+		return probeResult{detail: "body does not match " + config.BodyMatch, cause: causeBody}
+	}
+	if utf8.RuneCountInString(config.GoldenFile) > 0 && string(body) != config.golden {
+		diff := unifiedDiff(config.golden, string(body), maxDiffLines)
+		return probeResult{detail: "body differs from " + config.GoldenFile + ":\n```\n" + diff + "```", cause: causeBody}
+	}
+	if len(config.JSONThresholds) == 0 {
+		return probeResult{ok: true, bodyHash: bodyHash}
+	}
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return probeResult{detail: "invalid JSON body", cause: causeBody}
+	}
+	for _, threshold := range config.JSONThresholds {
+		if !checkJSONThreshold(doc, threshold) {
+			return probeResult{detail: fmt.Sprintf("JSON %s %s %v failed", threshold.Path, threshold.Op, threshold.Value), cause: causeBody}
+		}
+	}
+	return probeResult{ok: true, bodyHash: bodyHash}
+}
+
+func needsBody(config CheckConfig) bool {
+	return len(config.JSONThresholds) > 0 ||
+		utf8.RuneCountInString(config.GoldenFile) > 0 ||
+		utf8.RuneCountInString(config.BodyMatch) > 0 ||
+		utf8.RuneCountInString(config.BodyContains) > 0 ||
+		config.DetectChanges
+}
+
+func drainBody(body io.ReadCloser) {
+	io.CopyN(io.Discard, body, maxDrainBytes)
+	body.Close()
+}
+
+func checkClockSkew(resp *http.Response, maxSkew time.Duration) (probeResult, bool) {
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return probeResult{detail: "missing or invalid Date header", cause: causeClockSkew}, false
+	}
+	skew := time.Since(date)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxSkew {
+		return probeResult{
+			detail: fmt.Sprintf("clock skew %s exceeds %s", skew.Truncate(time.Second), maxSkew),
+			cause:  causeClockSkew,
+		}, false
+	}
+	return probeResult{}, true
+}
+
+func lookupJSONPath(doc interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			doc = value
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			doc = node[idx]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+func compareFloat(a float64, op string, b float64) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	default:
+		return false
+	}
+}
+
+func checkJSONThreshold(doc interface{}, threshold JSONThreshold) bool {
+	value, ok := lookupJSONPath(doc, threshold.Path)
+	if !ok {
+		return false
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return false
+	}
+	return compareFloat(number, threshold.Op, threshold.Value)
+}
+
+func max(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func intInSlice(a int, slice []int) bool {
+	for _, b := range slice {
+		if a == b {
+			return true
+		}
+	}
+	return false
+}
+
+func getNewStatus(history []checkStatus, okPeriods int, alarmPeriods int) checkStatus {
+	size := len(history)
+	if history[size-1] == checkStatusOk {
+		var ok = true
+		for idx := size - 2; idx >= size-okPeriods; idx-- {
+			if history[idx] != checkStatusOk {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return checkStatusOk
+		}
+	}
+	if history[size-1] == checkStatusAlarm {
+		var alarm = true
+		for idx := size - 2; idx >= size-alarmPeriods; idx-- {
+			if history[idx] != checkStatusAlarm {
+				alarm = false
+				break
+			}
+		}
+		if alarm {
+			return checkStatusAlarm
+		}
+	}
+	if history[size-1] == checkStatusDegraded {
+		var degraded = true
+		for idx := size - 2; idx >= size-alarmPeriods; idx-- {
+			if history[idx] != checkStatusDegraded {
+				degraded = false
+				break
+			}
+		}
+		if degraded {
+			return checkStatusDegraded
+		}
+	}
+	return checkStatusUnknown
+}
+
+func timeoutStatus(timeoutStreak int, timeoutPeriods int) checkStatus {
+	if timeoutStreak >= timeoutPeriods {
+		return checkStatusAlarm
+	}
+	return checkStatusUnknown
+}
+
+func newHTTPClient(config CheckConfig, network string) *http.Client {
+	client := &http.Client{
+		Timeout:       config.HTTPTimeout.Duration,
+		CheckRedirect: ignoreRedirect,
+	}
+	if config.FollowRedirects {
+		client.CheckRedirect = limitRedirects(config.MaxRedirects)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	configureTransport(transport, config)
+	client.Transport = transport
+	if utf8.RuneCountInString(config.SourceIP) > 0 || utf8.RuneCountInString(network) > 0 {
+		dialer := &net.Dialer{Timeout: config.HTTPTimeout.Duration}
+		if utf8.RuneCountInString(config.SourceIP) > 0 {
+			dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(config.SourceIP)}
+		}
+		transport.DialContext = func(ctx context.Context, defaultNetwork, addr string) (net.Conn, error) {
+			if utf8.RuneCountInString(network) > 0 {
+				return dialer.DialContext(ctx, network, addr)
+			}
+			return dialer.DialContext(ctx, defaultNetwork, addr)
+		}
+	}
+	return client
+}
+
+func probeHTTP(ctx context.Context, client *http.Client, config CheckConfig) probeResult {
+	req, err := newRequest(config)
+	if err != nil {
+		return probeResult{detail: err.Error()}
+	}
+	var phases requestPhases
+	req = req.WithContext(httptrace.WithClientTrace(ctx, phases.trace()))
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := client.Do(req)
+	result := checkResponse(resp, err, config)
+	if result.timeout {
+		result.detail = "timeout during " + phases.stage()
+	}
+	result.phases = phases.timings()
+	if resp != nil {
+		result.statusCode = resp.StatusCode
+		if resp.TLS != nil {
+			result.certExpiry = earliestExpiry(resp.TLS.PeerCertificates)
+		}
+	}
+	return result
+}
+
+type prober struct {
+	client   *http.Client
+	client4  *http.Client
+	client6  *http.Client
+	resolver *net.Resolver
+	points   []vantagePoint
+	registry *statusRegistry
+}
+
+func newProber(config CheckConfig, registry *statusRegistry) *prober {
+	p := &prober{client: newHTTPClient(config, ""), registry: registry}
+	if config.Type == checkTypeDualStack {
+		p.client4 = newHTTPClient(config, "tcp4")
+		p.client6 = newHTTPClient(config, "tcp6")
+	}
+	if config.Type == checkTypeDNS {
+		p.resolver = newResolver(config)
+	}
+	if config.Type == checkTypeHTTP {
+		p.points = newVantagePoints(config, p.client)
+	}
+	return p
+}
+
+func (p *prober) probe(ctx context.Context, config CheckConfig) probeResult {
+	result := p.probeTarget(ctx, config)
+	if config.Invert {
+		return invertResult(result)
+	}
+	return result
+}
+
+func invertResult(result probeResult) probeResult {
+	if !result.ok {
+		return probeResult{ok: true, statusCode: result.statusCode}
+	}
+	detail := "target is reachable, expected it to fail"
+	if result.statusCode > 0 {
+		detail = fmt.Sprintf("target answered with status %d, expected it to fail", result.statusCode)
+	}
+	return probeResult{detail: detail, statusCode: result.statusCode}
+}
+
+func (p *prober) probeTarget(ctx context.Context, config CheckConfig) probeResult {
+	switch config.Type {
+	case checkTypeExec:
+		return runCommand(ctx, config)
+	case checkTypeDualStack:
+		return probeDualStack(ctx, p.client4, p.client6, config)
+	case checkTypeTCP:
+		return probeTCP(ctx, config)
+	case checkTypeICMP:
+		return probeICMP(ctx, config)
+	case checkTypeTLS:
+		return probeTLS(ctx, config)
+	case checkTypeDNS:
+		return probeDNS(ctx, p.resolver, config)
+	case checkTypeHeartbeat:
+		return probeHeartbeat(p.registry, config)
+	case checkTypeGRPC:
+		return probeGRPC(ctx, config)
+	default:
+		if checker, ok := lookupChecker(config.Type); ok {
+			return checker.Check(ctx, config).probeResult()
+		}
+		if len(p.points) > 0 {
+			return probeReplicas(ctx, p.points, config)
+		}
+		return probeHTTP(ctx, p.client, config)
+	}
+}
+
+func checkResponseTime(config CheckConfig, result probeResult, elapsed time.Duration) probeResult {
+	if !result.ok || config.MaxResponseTime.Duration == 0 || elapsed <= config.MaxResponseTime.Duration {
+		return result
+	}
+	result.ok = false
+	result.cause = causeSlow
+	result.detail = fmt.Sprintf(
+		"response took %s, over %s",
+		elapsed.Truncate(time.Millisecond),
+		config.MaxResponseTime,
+	)
+	return result
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case severityWarning:
+		return 1
+	case severityCritical:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func escalatedSeverity(steps []EscalationStep, streak int) (string, bool) {
+	var severity string
+	var found bool
+	for _, step := range steps {
+		if streak >= step.Streak && severityRank(step.Severity) > severityRank(severity) {
+			severity = step.Severity
+			found = true
+		}
+	}
+	return severity, found
+}
+
+func nextInterval(config CheckConfig) time.Duration {
+	jitter := config.Jitter.Duration
+	if jitter == 0 {
+		return config.CheckInterval.Duration
+	}
+	interval := config.CheckInterval.Duration + time.Duration(rand.Int63n(int64(2*jitter)+1)) - jitter
+	if interval < 0 {
+		return 0
+	}
+	return interval
+}
+
+func newStatusChange(config CheckConfig, from checkStatus, to checkStatus) statusChange {
+	return statusChange{
+		name:      config.Name,
+		url:       redactURL(config.URL),
+		time:      time.Now(),
+		from:      from,
+		to:        to,
+		source:    config.SourceIP,
+		tags:      config.Tags,
+		channel:   config.SlackChannel,
+		botName:   config.SlackBotName,
+		iconEmoji: config.SlackIconEmoji,
+		message:   config.messageTemplate,
+	}
+}
+
+var droppedEvents atomic.Uint64
+
+func escalateChange(config CheckConfig, change *statusChange) {
+	change.tags = append(append([]string(nil), change.tags...), config.EscalationTags...)
+	if utf8.RuneCountInString(config.EscalationChannel) > 0 {
+		change.channel = config.EscalationChannel
+	}
+}
+
+func sendChange(events chan<- statusChange, change statusChange) {
+	select {
+	case events <- change:
+	default:
+		droppedEvents.Add(1)
+		slog.Warn("event queue is full, dropped notification", changeAttrs(change)...)
+	}
+}
+
+func watchURL(
+	ctx context.Context,
+	config CheckConfig,
+	registry *statusRegistry,
+	tel *telemetry,
+	stats *metrics,
+	events chan<- statusChange,
+	slots chan struct{},
+	trigger <-chan struct{},
+	errs chan<- error,
+) {
+	defer reportPanic(errs)
+
+	var lastStatus = registry.status(config.Name)
+	var history = make([]checkStatus, max(config.OKPeriods, config.AlarmPeriods))
+	var startedAt = time.Now()
+	var seenOK, neverHealthyReported bool
+	var failureStreak, timeoutStreak int
+	var lastSeverity string
+	var changes = newChangeDetector(config)
+	var certWarned bool
+	var alarmSince time.Time
+	var flaps = newFlapDetector(config)
+	var startupPending = config.notifyOnStart
+	var lastReminder = startedAt
+	var reminders int
+	var escalated bool
+
+	if config.Type == checkTypeExec {
+		slog.Info("run", "name", config.Name, "command", config.Command, "interval", config.CheckInterval.String())
+	} else if len(config.URLs) > 0 {
+		slog.Info("check", "name", config.Name, "urls", config.URLs, "interval", config.CheckInterval.String())
+	} else {
+		slog.Info("check", "name", config.Name, "url", redactURL(config.URL), "interval", config.CheckInterval.String())
+	}
+
+	prober := newProber(config, registry)
+
+	if config.Jitter.Duration > 0 || slots != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(rand.Int63n(int64(config.CheckInterval.Duration)))):
+		}
+	}
+
+	for {
+		if slots != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case slots <- struct{}{}:
+			}
+		}
+		start := time.Now()
+		probeCtx, span := tel.start(ctx, config, start)
+		result := prober.probe(probeCtx, config)
+		elapsed := time.Since(start)
+		if slots != nil {
+			<-slots
+		}
+		result = checkResponseTime(config, result, elapsed)
+
+		var currentStatus = checkStatusUnknown
+		if !result.ok {
+			currentStatus = checkStatusAlarm
+		} else if config.MaxLatency.Duration > 0 && elapsed > config.MaxLatency.Duration {
+			currentStatus = checkStatusDegraded
+			result.cause = causeSlow
+			result.detail = fmt.Sprintf(
+				"response took %s, over %s",
+				elapsed.Truncate(time.Millisecond),
+				config.MaxLatency,
+			)
+		} else {
+			currentStatus = checkStatusOk
+		}
+
+		tel.record(span, config, start, elapsed, currentStatus, result)
+		stats.recordCheck(config, currentStatus, result, elapsed.Seconds())
+		registry.recordCheck(config.Name, result.ok, start)
+		logCheck(config, currentStatus, result, elapsed)
+		if len(result.replicas) > 0 {
+			registry.recordReplicas(config.Name, result.replicas)
+			tel.recordReplicas(config, result.replicas)
+		}
+
+		if result.ok {
+			failureStreak = 0
+		} else {
+			failureStreak++
+		}
+		if result.timeout {
+			timeoutStreak++
+		} else {
+			timeoutStreak = 0
+		}
+
+		history = append(history[1:], currentStatus)
+
+		newStatus := getNewStatus(history, config.OKPeriods, config.AlarmPeriods)
+		if result.timeout && config.TimeoutPeriods > 0 {
+			newStatus = timeoutStatus(timeoutStreak, config.TimeoutPeriods)
+		}
+		if startupPending && newStatus != checkStatusUnknown {
+			startupPending = false
+			if newStatus == lastStatus {
+				change := newStatusChange(config, checkStatusUnknown, newStatus)
+				change.detail = result.detail
+				change.statusCode = result.statusCode
+				change.cause = result.cause
+				change.elapsed = elapsed
+				if newStatus == checkStatusAlarm {
+					change.severity = severityCritical
+					if result.timeout {
+						change.severity = config.TimeoutSeverity
+					}
+					lastSeverity = change.severity
+				}
+				registry.recordChange(change)
+				sendChange(events, change)
+			}
+		}
+		if newStatus != checkStatusUnknown && newStatus != lastStatus {
+			change := newStatusChange(config, lastStatus, newStatus)
+			change.detail = result.detail
+			change.statusCode = result.statusCode
+			change.cause = result.cause
+			change.elapsed = elapsed
+			if newStatus == checkStatusAlarm {
+				change.severity = severityCritical
+				if result.timeout {
+					change.severity = config.TimeoutSeverity
+				}
+				if severity, ok := escalatedSeverity(config.Escalation, failureStreak); ok {
+					change.severity = severity
+				}
+				lastSeverity = change.severity
+				alarmSince = change.time
+				lastReminder = change.time
+				reminders = 0
+			} else if lastStatus == checkStatusAlarm {
+				if !alarmSince.IsZero() {
+					change.downtime = change.time.Sub(alarmSince)
+					alarmSince = time.Time{}
+				}
+				if escalated {
+					escalateChange(config, &change)
+					escalated = false
+				}
+			}
+			if newStatus == checkStatusDegraded {
+				change.severity = severityWarning
+			}
+			registry.recordChange(change)
+			stats.recordChange(change)
+			slog.Info("status changed", changeAttrs(change)...)
+			switch flaps.transition(change.time) {
+			case flapStarted:
+				flapping := newStatusChange(config, lastStatus, newStatus)
+				flapping.flapping = true
+				flapping.severity = severityWarning
+				flapping.detail = fmt.Sprintf(
+					"more than %d status changes within %s, suppressing notifications",
+					config.FlapThreshold,
+					config.FlapWindow,
+				)
+				sendChange(events, flapping)
+			case flapSuppressed:
+				slog.Info("suppressed notification for flapping check", changeAttrs(change)...)
+			default:
+				sendChange(events, change)
+			}
+			lastStatus = newStatus
+		} else if lastStatus == checkStatusAlarm && !result.ok {
+			severity, ok := escalatedSeverity(config.Escalation, failureStreak)
+			if ok && severityRank(severity) > severityRank(lastSeverity) {
+				change := newStatusChange(config, checkStatusAlarm, checkStatusAlarm)
+				change.detail = fmt.Sprintf("escalated to %s after %d failed checks", severity, failureStreak)
+				change.severity = severity
+				registry.recordChange(change)
+				sendChange(events, change)
+				lastSeverity = severity
+			}
+		}
+
+		if lastStatus == checkStatusAlarm && config.RenotifyInterval.Duration > 0 &&
+			time.Since(lastReminder) >= config.RenotifyInterval.Duration {
+			reminders++
+			change := newStatusChange(config, checkStatusAlarm, checkStatusAlarm)
+			change.severity = lastSeverity
+			change.detail = fmt.Sprintf("still in alarm (reminder %d)", reminders)
+			if !alarmSince.IsZero() {
+				change.detail = fmt.Sprintf(
+					"still in alarm after %s (reminder %d)",
+					time.Since(alarmSince).Truncate(time.Second),
+					reminders,
+				)
+			}
+			if config.EscalateAfter > 0 && reminders >= config.EscalateAfter {
+				escalateChange(config, &change)
+				escalated = true
+			}
+			sendChange(events, change)
+			lastReminder = change.time
+		}
+
+		if suppressed, ok := flaps.stabilized(time.Now()); ok {
+			change := newStatusChange(config, lastStatus, lastStatus)
+			change.detail = fmt.Sprintf(
+				"stopped flapping, %d status changes suppressed",
+				suppressed,
+			)
+			registry.recordChange(change)
+			sendChange(events, change)
+		}
+
+		if config.CertExpiryWarning.Duration > 0 && !result.certExpiry.IsZero() {
+			expiring := time.Until(result.certExpiry) <= config.CertExpiryWarning.Duration
+			if expiring && !certWarned {
+				change := newStatusChange(config, lastStatus, lastStatus)
+				change.certExpiry = result.certExpiry
+				change.severity = severityWarning
+				if remaining := time.Until(result.certExpiry); remaining > 0 {
+					change.detail = fmt.Sprintf(
+						"certificate expires %s (in %s)",
+						result.certExpiry.Format(time.RFC1123),
+						remaining.Truncate(time.Hour),
+					)
+				} else {
+					change.detail = fmt.Sprintf("certificate expired %s", result.certExpiry.Format(time.RFC1123))
+				}
+				registry.recordChange(change)
+				sendChange(events, change)
+			}
+			certWarned = expiring
+		}
+
+		if result.ok && config.DetectChanges && changes.observe(result.bodyHash, time.Now()) {
+			change := newStatusChange(config, lastStatus, lastStatus)
+			change.detail = "content changed"
+			registry.recordChange(change)
+			sendChange(events, change)
+		}
+
+		if result.ok {
+			seenOK = true
+		}
+		if config.NeverHealthyAfter.Duration > 0 && !seenOK && !neverHealthyReported &&
+			time.Since(startedAt) >= config.NeverHealthyAfter.Duration {
+			neverHealthyReported = true
+			if lastStatus != checkStatusAlarm {
+				change := newStatusChange(config, lastStatus, checkStatusAlarm)
+				change.detail = fmt.Sprintf("no successful check since startup %s ago", time.Since(startedAt).Truncate(time.Second))
+				change.severity = severityCritical
+				registry.recordChange(change)
+				sendChange(events, change)
+				lastStatus = checkStatusAlarm
+				lastSeverity = change.severity
+				alarmSince = change.time
+				lastReminder = change.time
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(nextInterval(config)):
+		case <-trigger:
+		}
+	}
+}
+
+func validateURLConfig(config CheckConfig) error {
+	if utf8.RuneCountInString(config.Name) == 0 {
+		return errors.New("Name is empty")
+	}
+
+	switch config.Type {
+	case checkTypeExec:
+		if utf8.RuneCountInString(config.Command) == 0 {
+			return errors.New("empty Command")
+		}
+
+		if utf8.RuneCountInString(config.OutputMatch) > 0 {
+			if _, err := regexp.Compile(config.OutputMatch); err != nil {
+				return fmt.Errorf("invalid OutputMatch: %s", err.Error())
+			}
+		}
+	case checkTypeTCP, checkTypeTLS, checkTypeGRPC:
+		if _, _, err := net.SplitHostPort(config.URL); err != nil {
+			return fmt.Errorf("invalid URL: %s", err.Error())
+		}
+	case checkTypeICMP:
+		if utf8.RuneCountInString(config.URL) == 0 {
+			return errors.New("empty URL")
+		}
+	case checkTypeHeartbeat:
+		if utf8.RuneCountInString(config.Token) == 0 {
+			return errors.New("empty Token")
+		}
+
+		if config.ExpectedInterval.Duration == 0 {
+			return errors.New("ExpectedInterval == 0s")
+		}
+	case checkTypeDNS:
+		if utf8.RuneCountInString(config.URL) == 0 {
+			return errors.New("empty URL")
+		}
+
+		if !isKnownRecordType(config.RecordType) {
+			return fmt.Errorf("unknown RecordType %q", config.RecordType)
+		}
+
+		if utf8.RuneCountInString(config.Resolver) > 0 {
+			if _, _, err := net.SplitHostPort(config.Resolver); err != nil {
+				return fmt.Errorf("invalid Resolver: %s", err.Error())
+			}
+		}
+	case checkTypeHTTP, checkTypeDualStack:
+		if utf8.RuneCountInString(config.URL) == 0 && len(config.URLs) == 0 {
+			return errors.New("empty URL")
+		}
+
+		if (len(config.URLs) > 0 || len(config.Proxies) > 0) && config.Type == checkTypeDualStack {
+			return errors.New("URLs and Proxies are not supported by dualstack checks")
+		}
+
+		if len(config.URLs) > 0 && len(config.Proxies) > 0 {
+			return errors.New("URLs and Proxies are mutually exclusive")
+		}
+
+		if len(config.Proxies) > 0 && utf8.RuneCountInString(config.URL) == 0 {
+			return errors.New("Proxies need a URL")
+		}
+
+		for idx, proxy := range config.Proxies {
+			if _, err := url.ParseRequestURI(proxy); err != nil {
+				return fmt.Errorf("invalid Proxies %d: %s", idx, err.Error())
+			}
+		}
+
+		if config.Quorum < 0 || config.Quorum > len(config.URLs)+len(config.Proxies) {
+			return errors.New("Quorum must be between 0 and the number of URLs or Proxies")
+		}
+
+		if len(config.OKStatuses) == 0 {
+			return errors.New("empty OKStatuses")
+		}
+	default:
+		if _, ok := lookupChecker(config.Type); !ok {
+			return fmt.Errorf("unknown Type %q", config.Type)
+		}
+	}
+
+	for idx, window := range config.MaintenanceWindows {
+		if err := validateMaintenanceWindow(window); err != nil {
+			return fmt.Errorf("MaintenanceWindows %d: %s", idx, err.Error())
+		}
+	}
+
+	if config.RenotifyInterval.Duration < 0 || config.EscalateAfter < 0 {
+		return errors.New("RenotifyInterval and EscalateAfter must not be negative")
+	}
+
+	if config.EscalateAfter > 0 && config.RenotifyInterval.Duration == 0 {
+		return errors.New("EscalateAfter without RenotifyInterval")
+	}
+
+	if config.MaxLatency.Duration < 0 {
+		return errors.New("MaxLatency < 0s")
+	}
+
+	if config.TimeoutPeriods < 0 {
+		return errors.New("TimeoutPeriods < 0")
+	}
+
+	switch config.TimeoutSeverity {
+	case severityWarning, severityCritical:
+	default:
+		return fmt.Errorf("unknown TimeoutSeverity %q", config.TimeoutSeverity)
+	}
+
+	for idx, step := range config.Escalation {
+		if step.Streak <= 0 {
+			return fmt.Errorf("Escalation %d: Streak <= 0", idx)
+		}
+		if severityRank(step.Severity) == 0 {
+			return fmt.Errorf("Escalation %d: unknown Severity %q", idx, step.Severity)
+		}
+	}
+
+	if config.ChangePersistChecks < 0 || config.ChangeLimit < 0 {
+		return errors.New("ChangePersistChecks and ChangeLimit must not be negative")
+	}
+
+	if config.ChangeLimit > 0 && config.ChangeWindow.Duration == 0 {
+		return errors.New("ChangeWindow == 0s")
+	}
+
+	if config.FlapThreshold < 0 {
+		return errors.New("FlapThreshold < 0")
+	}
+
+	if config.FlapThreshold > 0 && config.FlapWindow.Duration == 0 {
+		return errors.New("FlapWindow == 0s")
+	}
+
+	if config.Invert && config.Type == checkTypeHeartbeat {
+		return errors.New("Invert is not supported by heartbeat checks")
+	}
+
+	if config.Invert && (config.MaxResponseTime.Duration > 0 || config.MaxLatency.Duration > 0) {
+		return errors.New("Invert can't be combined with MaxResponseTime or MaxLatency")
+	}
+
+	if config.MaxRedirects < 0 {
+		return errors.New("MaxRedirects < 0")
+	}
+
+	if err := validateTransport(config); err != nil {
+		return err
+	}
+
+	if utf8.RuneCountInString(config.BodyMatch) > 0 {
+		if _, err := regexp.Compile(config.BodyMatch); err != nil {
+			return fmt.Errorf("invalid BodyMatch: %s", err.Error())
+		}
+	}
+
+	if config.BodyMaxBytes < 0 {
+		return errors.New("BodyMaxBytes < 0")
+	}
+
+	if !isKnownMethod(config.Method) {
+		return fmt.Errorf("unknown Method %q", config.Method)
+	}
+
+	if config.Method == http.MethodHead && (needsBody(config) || utf8.RuneCountInString(config.Body) > 0) {
+		return errors.New("HEAD requests have no body to send or check")
+	}
+
+	if utf8.RuneCountInString(config.Body) > 0 {
+		if _, err := parseBodyTemplate(config.Body); err != nil {
+			return fmt.Errorf("invalid Body template: %s", err.Error())
+		}
+	}
+
+	if utf8.RuneCountInString(config.MessageTemplate) > 0 {
+		if _, err := parseMessageTemplate(config.MessageTemplate); err != nil {
+			return fmt.Errorf("invalid MessageTemplate: %s", err.Error())
+		}
+	}
+
+	if config.RequireOCSPStapling && !strings.HasPrefix(config.URL, "https://") {
+		return errors.New("RequireOCSPStapling needs an https URL")
+	}
+
+	if utf8.RuneCountInString(config.SourceIP) > 0 && net.ParseIP(config.SourceIP) == nil {
+		return fmt.Errorf("invalid SourceIP %q", config.SourceIP)
+	}
+
+	if config.CheckInterval.Duration <= 0 {
+		return errors.New("CheckInterval <= 0s")
+	}
+
+	if utf8.RuneCountInString(config.BasicAuthUser) > 0 && utf8.RuneCountInString(config.BearerToken) > 0 {
+		return errors.New("BasicAuthUser and BearerToken are mutually exclusive")
+	}
+
+	if utf8.RuneCountInString(config.BasicAuthUser) == 0 && utf8.RuneCountInString(config.BasicAuthPassword) > 0 {
+		return errors.New("BasicAuthPassword without BasicAuthUser")
+	}
+
+	if config.Jitter.Duration < 0 {
+		return errors.New("Jitter < 0s")
+	}
+
+	if config.HTTPTimeout.Seconds() == 0 {
+		return errors.New("HTTPTimeout == 0s")
+	}
+
+	if config.AlarmPeriods == 0 {
+		return errors.New("AlarmPeriods == 0")
+	}
+
+	if config.OKPeriods == 0 {
+		return errors.New("OKPeriods == 0")
+	}
+
+	for idx, threshold := range config.JSONThresholds {
+		if utf8.RuneCountInString(threshold.Path) == 0 {
+			return fmt.Errorf("JSONThresholds %d: empty Path", idx)
+		}
+		switch threshold.Op {
+		case "<", "<=", ">", ">=", "==", "!=":
+		default:
+			return fmt.Errorf("JSONThresholds %d: unknown Op %q", idx, threshold.Op)
+		}
+	}
+
+	return nil
+}
+
+func setURLDefaults(config *CheckConfig) {
+	if utf8.RuneCountInString(config.Type) == 0 {
+		config.Type = checkTypeHTTP
+	}
+	if utf8.RuneCountInString(config.Method) == 0 {
+		config.Method = http.MethodGet
+		if utf8.RuneCountInString(config.Body) > 0 {
+			config.Method = http.MethodPost
+		}
+	}
+	config.Method = strings.ToUpper(config.Method)
+	if config.Type == checkTypeDNS && utf8.RuneCountInString(config.RecordType) == 0 {
+		config.RecordType = "A"
+	}
+	config.RecordType = strings.ToUpper(config.RecordType)
+	if config.BodyMaxBytes == 0 {
+		config.BodyMaxBytes = defaultBodyMaxBytes
+	}
+	if utf8.RuneCountInString(config.TimeoutSeverity) == 0 {
+		config.TimeoutSeverity = severityCritical
+	}
+	if config.FollowRedirects && config.MaxRedirects == 0 {
+		config.MaxRedirects = 10
+	}
+}
+
+func setDefaults(config *Config) {
+	if config.HistorySize == 0 {
+		config.HistorySize = 20
+	}
+	if utf8.RuneCountInString(config.LogFormat) == 0 {
+		config.LogFormat = logFormatText
+	}
+	if utf8.RuneCountInString(config.LogLevel) == 0 {
+		config.LogLevel = "info"
+	}
+	if len(config.Notifiers) == 0 && utf8.RuneCountInString(config.SlackToken) > 0 {
+		config.Notifiers = []NotifierConfig{{
+			Type:    notifierTypeSlack,
+			Token:   config.SlackToken,
+			Channel: config.SlackChannel,
+			BotName: config.BotName,
+		}}
+	}
+	if config.Digest != nil {
+		for _, notifier := range config.Notifiers {
+			if notifier.Type != notifierTypeSlack {
+				continue
+			}
+			if utf8.RuneCountInString(config.Digest.Token) == 0 {
+				config.Digest.Token = notifier.Token
+			}
+			if utf8.RuneCountInString(config.Digest.Channel) == 0 {
+				config.Digest.Channel = notifier.Channel
+			}
+			if utf8.RuneCountInString(config.Digest.BotName) == 0 {
+				config.Digest.BotName = notifier.BotName
+			}
+			break
+		}
+	}
+	if config.HA != nil {
+		setHADefaults(config.HA)
+	}
+	for idx := range config.Items {
+		setCheckDefaults(*config, &config.Items[idx])
+	}
+}
+
+func setCheckDefaults(config Config, item *CheckConfig) {
+	if item.Jitter.Duration == 0 {
+		item.Jitter = config.Jitter
+	}
+	if item.FlapThreshold == 0 {
+		item.FlapThreshold = config.FlapThreshold
+	}
+	if item.FlapWindow.Duration == 0 {
+		item.FlapWindow = config.FlapWindow
+	}
+	if utf8.RuneCountInString(item.MessageTemplate) == 0 {
+		item.MessageTemplate = config.MessageTemplate
+	}
+	item.notifyOnStart = config.NotifyOnStart
+	applyRoutes(config.Routes, item)
+	applyTransportDefaults(config, item)
+	item.MaintenanceWindows = append(item.MaintenanceWindows, config.MaintenanceWindows...)
+	setURLDefaults(item)
+}
+
+func prepareURLConfig(config *CheckConfig) error {
+	if utf8.RuneCountInString(config.BodyMatch) > 0 {
+		re, err := regexp.Compile(config.BodyMatch)
+		if err != nil {
+			return err
+		}
+		config.bodyMatch = re
+	}
+	if utf8.RuneCountInString(config.OutputMatch) > 0 {
+		re, err := regexp.Compile(config.OutputMatch)
+		if err != nil {
+			return err
+		}
+		config.outputMatch = re
+	}
+	if utf8.RuneCountInString(config.Body) > 0 {
+		tmpl, err := parseBodyTemplate(config.Body)
+		if err != nil {
+			return err
+		}
+		config.bodyTemplate = tmpl
+	}
+	if utf8.RuneCountInString(config.MessageTemplate) > 0 {
+		tmpl, err := parseMessageTemplate(config.MessageTemplate)
+		if err != nil {
+			return err
+		}
+		config.messageTemplate = tmpl
+	}
+	if utf8.RuneCountInString(config.GoldenFile) > 0 {
+		golden, err := os.ReadFile(config.GoldenFile)
+		if err != nil {
+			return err
+		}
+		if len(golden) > config.BodyMaxBytes {
+			return fmt.Errorf("GoldenFile %q is larger than BodyMaxBytes (%d)", config.GoldenFile, config.BodyMaxBytes)
+		}
+		config.golden = string(golden)
+	}
+	tlsConfig, err := newTLSConfig(*config)
+	if err != nil {
+		return err
+	}
+	config.tlsConfig = tlsConfig
+	return nil
+}
+
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := decodeConfig(path, string(data), &config); err != nil {
+		return config, err
+	}
+	if err := expandEnv(&config); err != nil {
+		return config, err
+	}
+
+	if err := prepareConfig(&config); err != nil {
+		return config, err
+	}
+
+	if len(config.Notifiers) == 0 {
+		return config, errors.New("invalid config: no notifiers")
+	}
+
+	if len(config.Items) == 0 {
+		return config, errors.New("invalid config: no items")
+	}
+
+	return config, nil
+}
+
+func prepareConfig(config *Config) error {
+	setDefaults(config)
+
+	if err := validateConfig(*config); err != nil {
+		return fmt.Errorf("invalid config: %s", err.Error())
+	}
+
+	for idx := range config.Items {
+		if err := prepareURLConfig(&config.Items[idx]); err != nil {
+			return fmt.Errorf("invalid item %d: %s", idx, err.Error())
+		}
+	}
+
+	config.prepared = true
+	return nil
+}
+
+func validateConfig(config Config) error {
+	if _, err := NewLogHandler(config.LogFormat, config.LogLevel); err != nil {
+		return err
+	}
+
+	if config.Digest != nil {
+		if err := validateDigestConfig(*config.Digest); err != nil {
+			return fmt.Errorf("invalid Digest: %s", err.Error())
+		}
+	}
+
+	if config.HA != nil {
+		if err := validateHAConfig(*config.HA); err != nil {
+			return fmt.Errorf("invalid HA: %s", err.Error())
+		}
+	}
+
+	for idx, route := range config.Routes {
+		if err := validateRouteConfig(route); err != nil {
+			return fmt.Errorf("invalid route %d: %s", idx, err.Error())
+		}
+	}
+
+	for idx, conf := range config.Notifiers {
+		if err := validateNotifierConfig(conf); err != nil {
+			return fmt.Errorf("invalid notifier %d: %s", idx, err.Error())
+		}
+	}
+
+	if config.OutageThreshold < 0 || config.OutageThreshold > 1 {
+		return errors.New("OutageThreshold must be between 0 and 1")
+	}
+
+	if config.OutageThreshold > 0 && config.OutageWindow.Seconds() == 0 {
+		return errors.New("OutageWindow == 0s")
+	}
+
+	if config.HistorySize < 0 {
+		return errors.New("HistorySize < 0")
+	}
+
+	if config.BatchWindow.Duration < 0 {
+		return errors.New("BatchWindow < 0s")
+	}
+
+	if utf8.RuneCountInString(config.MetricsAddr) > 0 && config.MetricsAddr == config.APIAddr {
+		return errors.New("MetricsAddr and APIAddr must differ")
+	}
+
+	if utf8.RuneCountInString(config.APIToken) > 0 && utf8.RuneCountInString(config.APIAddr) == 0 {
+		return errors.New("APIToken without APIAddr")
+	}
+
+	if config.StartupRate < 0 {
+		return errors.New("StartupRate < 0")
+	}
+
+	if config.MaxConcurrentChecks < 0 {
+		return errors.New("MaxConcurrentChecks < 0")
+	}
+
+	if config.StateOnShutdown && utf8.RuneCountInString(config.StateFile) == 0 {
+		return errors.New("StateOnShutdown without StateFile")
+	}
+
+	names := make(map[string]bool, len(config.Items))
+	tokens := make(map[string]bool)
+	for idx, conf := range config.Items {
+		if err := validateURLConfig(conf); err != nil {
+			return fmt.Errorf("invalid item %d: %s", idx, err.Error())
+		}
+		if names[conf.Name] {
+			return fmt.Errorf("invalid item %d: duplicate Name %q", idx, conf.Name)
+		}
+		names[conf.Name] = true
+		if conf.Type != checkTypeHeartbeat {
+			continue
+		}
+		if utf8.RuneCountInString(config.APIAddr) == 0 {
+			return fmt.Errorf("invalid item %d: heartbeat checks need APIAddr", idx)
+		}
+		if tokens[conf.Token] {
+			return fmt.Errorf("invalid item %d: duplicate Token", idx)
+		}
+		tokens[conf.Token] = true
+	}
+
+	return validateDependencies(config.Items)
+}
+
+func stringToCheckStatus(status string) checkStatus {
+	switch status {
+	case "alarm":
+		return checkStatusAlarm
+	case "ok":
+		return checkStatusOk
+	case "degraded":
+		return checkStatusDegraded
+	default:
+		return checkStatusUnknown
+	}
+}
+
+func checkStatusToString(status checkStatus) string {
+	switch status {
+	case checkStatusAlarm:
+		return "alarm"
+	case checkStatusOk:
+		return "ok"
+	case checkStatusDegraded:
+		return "degraded"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,182 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultLeaderKey = "itsalive:leader"
+
+var renewLease = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+var releaseLease = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type HAConfig struct {
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	Key           string
+	NodeID        string
+	LeaseTTL      Duration
+}
+
+func validateHAConfig(config HAConfig) error {
+	if utf8.RuneCountInString(config.RedisAddr) == 0 {
+		return errors.New("empty RedisAddr")
+	}
+	if utf8.RuneCountInString(config.NodeID) == 0 {
+		return errors.New("empty NodeID")
+	}
+	if config.LeaseTTL.Duration <= 0 {
+		return errors.New("LeaseTTL <= 0s")
+	}
+	return nil
+}
+
+func setHADefaults(config *HAConfig) {
+	if utf8.RuneCountInString(config.Key) == 0 {
+		config.Key = defaultLeaderKey
+	}
+	if utf8.RuneCountInString(config.NodeID) == 0 {
+		config.NodeID, _ = os.Hostname()
+	}
+	if config.LeaseTTL.Duration == 0 {
+		config.LeaseTTL.Duration = 10 * time.Second
+	}
+}
+
+type leaderElector struct {
+	client *redis.Client
+	key    string
+	id     string
+	ttl    time.Duration
+	leader atomic.Bool
+	done   chan struct{}
+}
+
+func newLeaderElector(config HAConfig) *leaderElector {
+	return &leaderElector{
+		client: redis.NewClient(&redis.Options{
+			Addr:     config.RedisAddr,
+			Password: config.RedisPassword,
+			DB:       config.RedisDB,
+		}),
+		key:  config.Key,
+		id:   config.NodeID,
+		ttl:  config.LeaseTTL.Duration,
+		done: make(chan struct{}),
+	}
+}
+
+func (e *leaderElector) isLeader() bool {
+	if e == nil {
+		return true
+	}
+	return e.leader.Load()
+}
+
+func (e *leaderElector) wait() {
+	if e == nil {
+		return
+	}
+	<-e.done
+}
+
+func (e *leaderElector) acquire(ctx context.Context) (bool, error) {
+	if e.leader.Load() {
+		renewed, err := renewLease.Run(ctx, e.client, []string{e.key}, e.id, e.ttl.Milliseconds()).Int()
+		if err != nil || renewed == 1 {
+			return renewed == 1, err
+		}
+	}
+	return e.client.SetNX(ctx, e.key, e.id, e.ttl).Result()
+}
+
+func (e *leaderElector) run(ctx context.Context, events chan<- statusChange, errs chan<- error) {
+	defer reportPanic(errs)
+	defer close(e.done)
+	defer e.client.Close()
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	var failingSince time.Time
+	var alerted bool
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, e.ttl/3)
+		leader, err := e.acquire(attemptCtx)
+		cancel()
+		var alert *statusChange
+		if err != nil && ctx.Err() == nil {
+			slog.Error("leader election failed", "node", e.id, "error", err)
+			if failingSince.IsZero() {
+				failingSince = time.Now()
+			}
+			failingFor := time.Since(failingSince)
+			leader = e.leader.Load() || failingFor >= e.ttl
+			if failingFor >= e.ttl && !alerted {
+				alerted = true
+				alert = &statusChange{
+					name:     "itsalive-ha",
+					time:     time.Now(),
+					from:     checkStatusOk,
+					to:       checkStatusAlarm,
+					severity: severityCritical,
+					detail: fmt.Sprintf(
+						"leader election failing for %s, notifying from every node: %s",
+						failingFor.Truncate(time.Second),
+						err.Error(),
+					),
+				}
+			}
+		} else if err == nil {
+			failingSince = time.Time{}
+			if alerted {
+				alerted = false
+				alert = &statusChange{
+					name:   "itsalive-ha",
+					time:   time.Now(),
+					from:   checkStatusAlarm,
+					to:     checkStatusOk,
+					detail: "leader election recovered",
+				}
+			}
+		}
+		if e.leader.Swap(leader) != leader {
+			if leader {
+				slog.Info("became leader, sending notifications", "node", e.id)
+			} else {
+				slog.Warn("lost leadership, suppressing notifications", "node", e.id)
+			}
+		}
+		if alert != nil {
+			sendChange(events, *alert)
+		}
+
+		select {
+		case <-ctx.Done():
+			if e.leader.Swap(false) {
+				releaseLease.Run(context.Background(), e.client, []string{e.key}, e.id)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
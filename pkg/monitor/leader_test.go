@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLeaderElectionFailureAlertsFromFollower(t *testing.T) {
+	elector := newLeaderElector(HAConfig{
+		RedisAddr: "127.0.0.1:1",
+		Key:       defaultLeaderKey,
+		NodeID:    "follower",
+		LeaseTTL:  Duration{30 * time.Millisecond},
+	})
+
+	delivered := make(chan Event, 10)
+	notifier := eventNotifier{NotifierFunc(func(event Event) error {
+		delivered <- event
+		return nil
+	})}
+	events := make(chan statusChange, 10)
+	done := make(chan struct{})
+	go dispatchEvents([]changeNotifier{notifier}, [][]string{nil}, newStatusRegistry(10), elector, 0, events, done, make(chan error, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go elector.run(ctx, events, make(chan error, 1))
+
+	select {
+	case event := <-delivered:
+		if event.Name != "itsalive-ha" || event.To != "alarm" {
+			t.Fatalf("delivered %s -> %s, want itsalive-ha -> alarm", event.Name, event.To)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("election failure alert was not delivered from the follower")
+	}
+
+	cancel()
+	elector.wait()
+	close(events)
+	<-done
+}
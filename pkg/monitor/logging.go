@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+func NewLogHandler(format string, level string) (slog.Handler, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case logFormatText:
+		return slog.NewTextHandler(os.Stderr, opts), nil
+	case logFormatJSON:
+		return slog.NewJSONHandler(os.Stderr, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown LogFormat %q", format)
+	}
+}
+
+func logCheck(config CheckConfig, status checkStatus, result probeResult, elapsed time.Duration) {
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	attrs := []any{
+		"name", config.Name,
+		"url", redactURL(config.URL),
+		"status", checkStatusToString(status),
+		"latency", elapsed.Seconds(),
+	}
+	if result.statusCode > 0 {
+		attrs = append(attrs, "status_code", result.statusCode)
+	}
+	if !result.ok {
+		attrs = append(attrs, "error", result.detail)
+	}
+	slog.Debug("check result", attrs...)
+}
+
+func changeAttrs(change statusChange) []any {
+	attrs := []any{
+		"name", change.name,
+		"url", change.url,
+		"from", checkStatusToString(change.from),
+		"to", checkStatusToString(change.to),
+	}
+	if len(change.severity) > 0 {
+		attrs = append(attrs, "severity", change.severity)
+	}
+	if len(change.cause) > 0 {
+		attrs = append(attrs, "cause", change.cause)
+	}
+	if len(change.detail) > 0 {
+		attrs = append(attrs, "detail", change.detail)
+	}
+	if change.elapsed > 0 {
+		attrs = append(attrs, "latency", change.elapsed.Seconds())
+	}
+	if change.downtime > 0 {
+		attrs = append(attrs, "downtime", change.downtime.Seconds())
+	}
+	return attrs
+}
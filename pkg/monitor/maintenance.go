@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+type MaintenanceWindow struct {
+	Days  []string
+	Start string
+	End   string
+}
+
+func parseClock(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+func validateMaintenanceWindow(window MaintenanceWindow) error {
+	for _, day := range window.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("unknown day %q", day)
+		}
+	}
+	start, err := parseClock(window.Start)
+	if err != nil {
+		return fmt.Errorf("invalid Start: %s", err.Error())
+	}
+	end, err := parseClock(window.End)
+	if err != nil {
+		return fmt.Errorf("invalid End: %s", err.Error())
+	}
+	if start == end {
+		return errors.New("Start == End")
+	}
+	return nil
+}
+
+func (w MaintenanceWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		if weekdays[strings.ToLower(name)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (w MaintenanceWindow) contains(now time.Time) bool {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false
+	}
+	minutes := now.Hour()*60 + now.Minute()
+	if start < end {
+		return w.onDay(now.Weekday()) && minutes >= start && minutes < end
+	}
+	if minutes >= start {
+		return w.onDay(now.Weekday())
+	}
+	return minutes < end && w.onDay(now.AddDate(0, 0, -1).Weekday())
+}
+
+func inMaintenance(windows []MaintenanceWindow, now time.Time) bool {
+	for _, window := range windows {
+		if window.contains(now) {
+			return true
+		}
+	}
+	return false
+}
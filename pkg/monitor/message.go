@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type messageData struct {
+	Name       string
+	URL        string
+	From       string
+	To         string
+	Status     string
+	Detail     string
+	Source     string
+	Severity   string
+	Cause      string
+	Tags       []string
+	Time       time.Time
+	Duration   time.Duration
+	Latency    time.Duration
+	StatusCode int
+}
+
+func parseMessageTemplate(text string) (*template.Template, error) {
+	return template.New("message").Parse(text)
+}
+
+func renderMessage(change statusChange, label string) (string, error) {
+	data := messageData{
+		Name:       change.name,
+		URL:        change.url,
+		From:       checkStatusToString(change.from),
+		To:         checkStatusToString(change.to),
+		Status:     label,
+		Detail:     change.detail,
+		Source:     change.source,
+		Severity:   change.severity,
+		Cause:      change.cause,
+		Tags:       change.tags,
+		Time:       change.time,
+		Duration:   change.downtime.Truncate(time.Second),
+		Latency:    change.elapsed.Truncate(time.Millisecond),
+		StatusCode: change.statusCode,
+	}
+	var text bytes.Buffer
+	if err := change.message.Execute(&text, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(text.String()), nil
+}
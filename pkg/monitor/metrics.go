@@ -0,0 +1,141 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type metrics struct {
+	registry       *prometheus.Registry
+	status         *prometheus.GaugeVec
+	transitions    *prometheus.CounterVec
+	latency        *prometheus.GaugeVec
+	checks         *prometheus.CounterVec
+	failures       *prometheus.CounterVec
+	streak         *prometheus.GaugeVec
+	replicaStatus  *prometheus.GaugeVec
+	replicaLatency *prometheus.GaugeVec
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		status: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "itsalive_check_status",
+			Help: "Current check status (0=unknown, 1=ok, 2=alarm, 3=degraded).",
+		}, []string{"name", "url"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "itsalive_check_transitions_total",
+			Help: "Number of status transitions.",
+		}, []string{"name", "url", "to"}),
+		latency: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "itsalive_check_latency_seconds",
+			Help: "Latency of the last check.",
+		}, []string{"name", "url"}),
+		checks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "itsalive_checks_total",
+			Help: "Number of checks performed.",
+		}, []string{"name", "url"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "itsalive_check_failures_total",
+			Help: "Number of failed checks.",
+		}, []string{"name", "url"}),
+		streak: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "itsalive_check_consecutive_failures",
+			Help: "Number of failed checks in a row.",
+		}, []string{"name", "url"}),
+		replicaStatus: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "itsalive_replica_status",
+			Help: "Result of the last check of a replica (0=failing, 1=ok).",
+		}, []string{"name", "replica"}),
+		replicaLatency: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "itsalive_replica_latency_seconds",
+			Help: "Latency of the last check of a replica.",
+		}, []string{"name", "replica"}),
+	}
+	dropped := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "itsalive_dropped_events_total",
+		Help: "Number of notifications dropped because the event queue was full.",
+	}, func() float64 {
+		return float64(droppedEvents.Load())
+	})
+	m.registry.MustRegister(
+		m.status,
+		m.transitions,
+		m.latency,
+		m.checks,
+		m.failures,
+		m.streak,
+		m.replicaStatus,
+		m.replicaLatency,
+		dropped,
+	)
+	return m
+}
+
+func (m *metrics) recordCheck(config CheckConfig, status checkStatus, result probeResult, elapsed float64) {
+	if m == nil {
+		return
+	}
+	m.status.WithLabelValues(config.Name, redactURL(config.URL)).Set(float64(status))
+	m.latency.WithLabelValues(config.Name, redactURL(config.URL)).Set(elapsed)
+	m.checks.WithLabelValues(config.Name, redactURL(config.URL)).Inc()
+	if result.ok {
+		m.streak.WithLabelValues(config.Name, redactURL(config.URL)).Set(0)
+	} else {
+		m.failures.WithLabelValues(config.Name, redactURL(config.URL)).Inc()
+		m.streak.WithLabelValues(config.Name, redactURL(config.URL)).Inc()
+	}
+	for _, replica := range result.replicas {
+		ok := 0.0
+		if replica.ok {
+			ok = 1
+		}
+		m.replicaStatus.WithLabelValues(config.Name, replica.url).Set(ok)
+		m.replicaLatency.WithLabelValues(config.Name, replica.url).Set(replica.elapsed.Seconds())
+	}
+}
+
+func (m *metrics) recordChange(change statusChange) {
+	if m == nil {
+		return
+	}
+	m.transitions.WithLabelValues(change.name, change.url, checkStatusToString(change.to)).Inc()
+}
+
+func (m *metrics) forget(config CheckConfig) {
+	if m == nil {
+		return
+	}
+	labels := prometheus.Labels{"name": config.Name}
+	m.status.DeletePartialMatch(labels)
+	m.transitions.DeletePartialMatch(labels)
+	m.latency.DeletePartialMatch(labels)
+	m.checks.DeletePartialMatch(labels)
+	m.failures.DeletePartialMatch(labels)
+	m.streak.DeletePartialMatch(labels)
+	m.replicaStatus.DeletePartialMatch(labels)
+	m.replicaLatency.DeletePartialMatch(labels)
+}
+
+func serveMetrics(ctx context.Context, addr string, m *metrics, errs chan<- error) {
+	defer reportPanic(errs)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	slog.Info("metrics listening", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		panic(err)
+	}
+}
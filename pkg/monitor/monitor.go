@@ -0,0 +1,432 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type Event struct {
+	Name       string
+	URL        string
+	Time       time.Time
+	From       string
+	To         string
+	Detail     string
+	Source     string
+	Severity   string
+	Cause      string
+	Tags       []string
+	StatusCode int
+	Latency    time.Duration
+	Downtime   time.Duration
+	CertExpiry time.Time
+	Flapping   bool
+}
+
+func eventFromChange(change statusChange) Event {
+	return Event{
+		Name:       change.name,
+		URL:        change.url,
+		Time:       change.time,
+		From:       checkStatusToString(change.from),
+		To:         checkStatusToString(change.to),
+		Detail:     change.detail,
+		Source:     change.source,
+		Severity:   change.severity,
+		Cause:      change.cause,
+		Tags:       change.tags,
+		StatusCode: change.statusCode,
+		Latency:    change.elapsed,
+		Downtime:   change.downtime,
+		CertExpiry: change.certExpiry,
+		Flapping:   change.flapping,
+	}
+}
+
+type Notifier interface {
+	Notify(event Event) error
+}
+
+type NotifierFunc func(event Event) error
+
+func (f NotifierFunc) Notify(event Event) error {
+	return f(event)
+}
+
+type eventNotifier struct {
+	notifier Notifier
+}
+
+func (n eventNotifier) Notify(change statusChange) error {
+	return n.notifier.Notify(eventFromChange(change))
+}
+
+type Result struct {
+	OK         bool
+	Detail     string
+	Timeout    bool
+	StatusCode int
+}
+
+func (r Result) probeResult() probeResult {
+	return probeResult{ok: r.OK, detail: r.Detail, timeout: r.Timeout, statusCode: r.StatusCode}
+}
+
+type Checker interface {
+	Check(ctx context.Context, config CheckConfig) Result
+}
+
+type CheckerFunc func(ctx context.Context, config CheckConfig) Result
+
+func (f CheckerFunc) Check(ctx context.Context, config CheckConfig) Result {
+	return f(ctx, config)
+}
+
+var builtinCheckTypes = []string{
+	checkTypeHTTP,
+	checkTypeExec,
+	checkTypeDualStack,
+	checkTypeTCP,
+	checkTypeICMP,
+	checkTypeTLS,
+	checkTypeDNS,
+	checkTypeHeartbeat,
+	checkTypeGRPC,
+}
+
+var checkers = struct {
+	sync.RWMutex
+	types map[string]Checker
+}{types: make(map[string]Checker)}
+
+func RegisterChecker(checkType string, checker Checker) {
+	checkers.Lock()
+	defer checkers.Unlock()
+	for _, builtin := range builtinCheckTypes {
+		if checkType == builtin {
+			panic(fmt.Sprintf("check type %q is built in", checkType))
+		}
+	}
+	if _, ok := checkers.types[checkType]; ok {
+		panic(fmt.Sprintf("check type %q is already registered", checkType))
+	}
+	checkers.types[checkType] = checker
+}
+
+func lookupChecker(checkType string) (Checker, bool) {
+	checkers.RLock()
+	defer checkers.RUnlock()
+	checker, ok := checkers.types[checkType]
+	return checker, ok
+}
+
+type Monitor struct {
+	sync.Mutex
+	config    Config
+	registry  *statusRegistry
+	notifiers []changeNotifier
+	watchers  *watcherSet
+	added     map[string]bool
+}
+
+func New(config Config) (*Monitor, error) {
+	if !config.prepared {
+		if err := prepareConfig(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	registry := newStatusRegistry(config.HistorySize)
+
+	if utf8.RuneCountInString(config.StateFile) > 0 {
+		snapshots, err := loadState(config.StateFile)
+		if err != nil {
+			slog.Warn("ignoring state file", "error", err)
+		}
+		registry.restore(snapshots)
+		if config.StateOnShutdown {
+			if err := os.Remove(config.StateFile); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		} else {
+			registry.persistTo(config.StateFile)
+		}
+	}
+
+	if utf8.RuneCountInString(config.DowntimeLog) > 0 {
+		registry.logDowntimeTo(config.DowntimeLog)
+	}
+
+	return &Monitor{config: config, registry: registry, added: make(map[string]bool)}, nil
+}
+
+func (m *Monitor) AddNotifier(notifier Notifier) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.watchers != nil {
+		return errors.New("notifiers must be added before Run")
+	}
+	m.notifiers = append(m.notifiers, eventNotifier{notifier})
+	return nil
+}
+
+func (m *Monitor) AddCheck(check CheckConfig) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if containsCheck(m.config.Items, check.Name) {
+		return fmt.Errorf("invalid check: duplicate Name %q", check.Name)
+	}
+	items := append(append([]CheckConfig(nil), m.config.Items...), check)
+	added := &items[len(items)-1]
+	if err := m.prepareCheck(added, items); err != nil {
+		return err
+	}
+
+	if m.watchers != nil && !m.watchers.start(*added) {
+		return errMonitorStopping
+	}
+	m.config.Items = items
+	m.added[added.Name] = true
+	return nil
+}
+
+func (m *Monitor) replaceCheck(check CheckConfig) error {
+	m.Lock()
+	defer m.Unlock()
+
+	items := append([]CheckConfig(nil), m.config.Items...)
+	var replaced *CheckConfig
+	for idx := range items {
+		if items[idx].Name == check.Name {
+			items[idx] = check
+			replaced = &items[idx]
+			break
+		}
+	}
+	if replaced == nil || m.watchers == nil {
+		return errUnknownCheck
+	}
+	if err := m.prepareCheck(replaced, items); err != nil {
+		return err
+	}
+
+	if !m.watchers.replace(*replaced) {
+		return errUnknownCheck
+	}
+	m.config.Items = items
+	return nil
+}
+
+func (m *Monitor) prepareCheck(check *CheckConfig, items []CheckConfig) error {
+	setCheckDefaults(m.config, check)
+	if err := validateURLConfig(*check); err != nil {
+		return fmt.Errorf("invalid check: %s", err.Error())
+	}
+	if err := validateDependency(items, *check); err != nil {
+		return fmt.Errorf("invalid check: %s", err.Error())
+	}
+	if err := prepareURLConfig(check); err != nil {
+		return fmt.Errorf("invalid check: %s", err.Error())
+	}
+	return nil
+}
+
+var (
+	errUnknownCheck    = errors.New("unknown check")
+	errMonitorStopping = errors.New("monitor is stopping")
+)
+
+func (m *Monitor) RemoveCheck(name string) bool {
+	m.Lock()
+	defer m.Unlock()
+	for idx, item := range m.config.Items {
+		if item.Name != name {
+			continue
+		}
+		m.config.Items = append(m.config.Items[:idx:idx], m.config.Items[idx+1:]...)
+		delete(m.added, name)
+		if m.watchers != nil {
+			m.watchers.stop(name)
+		}
+		return true
+	}
+	return false
+}
+
+func (m *Monitor) Reload(path string) error {
+	config, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if m.watchers == nil {
+		return errors.New("monitor is not running")
+	}
+	if !reflect.DeepEqual(config.Notifiers, m.config.Notifiers) {
+		slog.Warn("notifier changes are ignored until restart")
+	}
+	items := config.Items
+	for _, item := range m.config.Items {
+		if !m.added[item.Name] {
+			continue
+		}
+		if containsCheck(config.Items, item.Name) {
+			delete(m.added, item.Name)
+			continue
+		}
+		items = append(items, item)
+	}
+	m.config.Items = items
+	started, stopped, restarted := m.watchers.reconcile(items)
+	slog.Info("reloaded config", "started", started, "stopped", stopped, "restarted", restarted)
+	return nil
+}
+
+func (m *Monitor) startCheck(name string) {
+	m.Lock()
+	defer m.Unlock()
+	for _, item := range m.config.Items {
+		if item.Name == name {
+			m.watchers.start(item)
+			return
+		}
+	}
+}
+
+func containsCheck(items []CheckConfig, name string) bool {
+	for _, item := range items {
+		if item.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Monitor) Run(ctx context.Context) error {
+	m.Lock()
+	if m.watchers != nil {
+		m.Unlock()
+		return errors.New("monitor is already running")
+	}
+	config := m.config
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, 1)
+
+	var elector *leaderElector
+	if config.HA != nil {
+		elector = newLeaderElector(*config.HA)
+	}
+
+	notifiers, filters, err := newNotifiers(ctx, config.Notifiers, m.notifiers, m.registry, elector, errs)
+	if err != nil {
+		m.Unlock()
+		return err
+	}
+
+	var tel *telemetry
+	if utf8.RuneCountInString(config.OTLPEndpoint) > 0 {
+		tel, err = newTelemetry(config.OTLPEndpoint, config.OTLPInsecure)
+		if err != nil {
+			m.Unlock()
+			return err
+		}
+	}
+
+	for _, conf := range config.Items {
+		m.registry.register(conf)
+	}
+
+	events := make(chan statusChange, 100)
+	notified := make(chan struct{})
+	go dispatchEvents(notifiers, filters, m.registry, elector, config.BatchWindow.Duration, events, notified, errs)
+
+	checkEvents := events
+	if config.OutageThreshold > 0 {
+		checkEvents = make(chan statusChange, 100)
+		go detectOutage(config, m.registry, checkEvents, events, errs)
+	}
+
+	if elector != nil {
+		go elector.run(ctx, events, errs)
+	}
+
+	var stats *metrics
+	if utf8.RuneCountInString(config.MetricsAddr) > 0 {
+		stats = newMetrics()
+		go serveMetrics(ctx, config.MetricsAddr, stats, errs)
+	}
+
+	watchers := newWatcherSet(ctx, m.registry, tel, stats, checkEvents, errs, config.MaxConcurrentChecks)
+	m.watchers = watchers
+	m.Unlock()
+
+	if utf8.RuneCountInString(config.APIAddr) > 0 {
+		go serveAPI(ctx, config.APIAddr, m.registry, m, config.APIToken, errs)
+	}
+
+	if utf8.RuneCountInString(config.CommandToken) > 0 {
+		go serveSlackCommands(ctx, config.CommandToken, m.registry, watchers, elector, errs)
+	}
+
+	if config.Digest != nil {
+		go runDigest(ctx, *config.Digest, m.registry, elector, errs)
+	}
+
+	for idx, conf := range config.Items {
+		if config.StartupRate > 0 && idx > 0 {
+			select {
+			case <-ctx.Done():
+			case err = <-errs:
+				slog.Error("stopping monitor", "error", err)
+				cancel()
+			case <-time.After(time.Second / time.Duration(config.StartupRate)):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if config.StartupRate > 0 && idx > 0 && idx%config.StartupRate == 0 {
+			slog.Info("starting checks", "started", idx, "total", len(config.Items))
+		}
+		m.startCheck(conf.Name)
+	}
+	if config.StartupRate > 0 && ctx.Err() == nil {
+		slog.Info("started all checks", "total", len(config.Items))
+	}
+
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+		slog.Error("stopping monitor", "error", err)
+		cancel()
+	}
+
+	watchers.wait()
+	elector.wait()
+	close(checkEvents)
+	<-notified
+
+	if config.StateOnShutdown && err == nil {
+		if saveErr := saveState(config.StateFile, m.registry.snapshot()); saveErr != nil {
+			err = fmt.Errorf("failed to save state: %s", saveErr.Error())
+		}
+	}
+
+	if shutdownErr := tel.shutdown(context.Background()); shutdownErr != nil && err == nil {
+		err = fmt.Errorf("failed to flush telemetry: %s", shutdownErr.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,285 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	notifierTypeSlack      = "slack"
+	notifierTypeSlackBoard = "slack-board"
+	notifierTypeWebhook    = "webhook"
+	notifierTypeEmail      = "email"
+	notifierTypePagerDuty  = "pagerduty"
+)
+
+type changeNotifier interface {
+	Notify(change statusChange) error
+}
+
+type batchNotifier interface {
+	NotifyBatch(changes []statusChange) error
+}
+
+type NotifierConfig struct {
+	Type            string
+	Tags            []string
+	Token           string
+	Channel         string
+	BotName         string
+	RefreshInterval Duration
+	URL             string
+	SMTPAddr        string
+	Username        string
+	Password        string
+	From            string
+	To              []string
+	RoutingKey      string
+}
+
+func validateNotifierConfig(config NotifierConfig) error {
+	switch config.Type {
+	case notifierTypeSlack, notifierTypeSlackBoard:
+		if utf8.RuneCountInString(config.Token) == 0 {
+			return errors.New("empty Token")
+		}
+
+		if utf8.RuneCountInString(config.Channel) == 0 {
+			return errors.New("empty Channel")
+		}
+
+		if utf8.RuneCountInString(config.BotName) == 0 {
+			return errors.New("empty BotName")
+		}
+	case notifierTypeWebhook:
+		if utf8.RuneCountInString(config.URL) == 0 {
+			return errors.New("empty URL")
+		}
+
+		if _, err := url.ParseRequestURI(config.URL); err != nil {
+			return fmt.Errorf("invalid URL: %s", err.Error())
+		}
+	case notifierTypeEmail:
+		if _, _, err := net.SplitHostPort(config.SMTPAddr); err != nil {
+			return fmt.Errorf("invalid SMTPAddr: %s", err.Error())
+		}
+
+		if utf8.RuneCountInString(config.From) == 0 {
+			return errors.New("empty From")
+		}
+
+		if len(config.To) == 0 {
+			return errors.New("empty To")
+		}
+	case notifierTypePagerDuty:
+		if utf8.RuneCountInString(config.RoutingKey) == 0 {
+			return errors.New("empty RoutingKey")
+		}
+
+		if utf8.RuneCountInString(config.URL) > 0 {
+			if _, err := url.ParseRequestURI(config.URL); err != nil {
+				return fmt.Errorf("invalid URL: %s", err.Error())
+			}
+		}
+	default:
+		return fmt.Errorf("unknown Type %q", config.Type)
+	}
+	return nil
+}
+
+func matchesTags(filter []string, tags []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		for _, wanted := range filter {
+			if tag == wanted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newNotifier(
+	ctx context.Context,
+	config NotifierConfig,
+	registry *statusRegistry,
+	elector *leaderElector,
+	errs chan<- error,
+) (changeNotifier, error) {
+	switch config.Type {
+	case notifierTypeSlack:
+		return newSlackNotifier(config), nil
+	case notifierTypeSlackBoard:
+		return newSlackStatusBoard(ctx, config, registry, elector, errs), nil
+	case notifierTypeWebhook:
+		return newWebhookNotifier(config), nil
+	case notifierTypeEmail:
+		return newEmailNotifier(config), nil
+	case notifierTypePagerDuty:
+		return newPagerDutyNotifier(config), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", config.Type)
+	}
+}
+
+func runNotifier(
+	notifier changeNotifier,
+	events <-chan statusChange,
+	batchWindow time.Duration,
+	wg *sync.WaitGroup,
+	errs chan<- error,
+) {
+	defer reportPanic(errs)
+	defer wg.Done()
+
+	if batcher, ok := notifier.(batchNotifier); ok && batchWindow > 0 {
+		runBatchNotifier(notifier, batcher, events, batchWindow)
+		return
+	}
+
+	for change := range events {
+		slog.Debug("notifying", changeAttrs(change)...)
+
+		if err := notifier.Notify(change); err != nil {
+			slog.Error("failed to notify", append(changeAttrs(change), "error", err)...)
+		}
+	}
+}
+
+func runBatchNotifier(notifier changeNotifier, batcher batchNotifier, events <-chan statusChange, window time.Duration) {
+	var batch []statusChange
+	var flush <-chan time.Time
+
+	send := func() {
+		var err error
+		if len(batch) == 1 {
+			err = notifier.Notify(batch[0])
+		} else {
+			err = batcher.NotifyBatch(batch)
+		}
+		if err != nil {
+			slog.Error("failed to notify", "changes", len(batch), "error", err)
+		}
+		batch = nil
+		flush = nil
+	}
+
+	for {
+		select {
+		case change, ok := <-events:
+			if !ok {
+				if len(batch) > 0 {
+					send()
+				}
+				return
+			}
+			slog.Debug("notifying", changeAttrs(change)...)
+			batch = append(batch, change)
+			if flush == nil {
+				flush = time.After(window)
+			}
+		case <-flush:
+			send()
+		}
+	}
+}
+
+const notifierQueueSize = 100
+
+func enqueue(out chan statusChange, change statusChange) {
+	for {
+		select {
+		case out <- change:
+			return
+		default:
+		}
+		select {
+		case dropped := <-out:
+			droppedEvents.Add(1)
+			slog.Warn("notifier queue is full, dropped oldest event", changeAttrs(dropped)...)
+		default:
+		}
+	}
+}
+
+func newNotifiers(
+	ctx context.Context,
+	configs []NotifierConfig,
+	extra []changeNotifier,
+	registry *statusRegistry,
+	elector *leaderElector,
+	errs chan<- error,
+) ([]changeNotifier, [][]string, error) {
+	notifiers := make([]changeNotifier, 0, len(configs)+len(extra))
+	filters := make([][]string, 0, len(configs)+len(extra))
+	for idx, config := range configs {
+		notifier, err := newNotifier(ctx, config, registry, elector, errs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("notifier %d: %s", idx, err.Error())
+		}
+		notifiers = append(notifiers, notifier)
+		filters = append(filters, config.Tags)
+	}
+	for _, notifier := range extra {
+		notifiers = append(notifiers, notifier)
+		filters = append(filters, nil)
+	}
+	return notifiers, filters, nil
+}
+
+func dispatchEvents(
+	notifiers []changeNotifier,
+	filters [][]string,
+	registry *statusRegistry,
+	elector *leaderElector,
+	batchWindow time.Duration,
+	events <-chan statusChange,
+	done chan<- struct{},
+	errs chan<- error,
+) {
+	defer reportPanic(errs)
+	defer close(done)
+
+	var wg sync.WaitGroup
+	outs := make([]chan statusChange, len(notifiers))
+	for idx, notifier := range notifiers {
+		outs[idx] = make(chan statusChange, notifierQueueSize)
+		wg.Add(1)
+		go runNotifier(notifier, outs[idx], batchWindow, &wg, errs)
+	}
+
+	for change := range events {
+		if !elector.isLeader() {
+			slog.Debug("not the leader, skipping notification", changeAttrs(change)...)
+			continue
+		}
+		if registry.silenced(change.name, change.time) {
+			slog.Info("silenced notification", changeAttrs(change)...)
+			continue
+		}
+		if registry.suppressed(change.name) {
+			slog.Info("suppressed notification", changeAttrs(change)...)
+			continue
+		}
+		change = annotateDependents(registry, change)
+		for idx, filter := range filters {
+			if matchesTags(filter, change.tags) {
+				enqueue(outs[idx], change)
+			}
+		}
+	}
+
+	for _, out := range outs {
+		close(out)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,37 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+func checkOCSPStapling(resp *http.Response) error {
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return errors.New("not a TLS connection")
+	}
+	if len(resp.TLS.OCSPResponse) == 0 {
+		return errors.New("no stapled OCSP response")
+	}
+
+	certs := resp.TLS.PeerCertificates
+	var issuer = certs[0]
+	if len(certs) > 1 {
+		issuer = certs[1]
+	}
+
+	staple, err := ocsp.ParseResponseForCert(resp.TLS.OCSPResponse, certs[0], issuer)
+	if err != nil {
+		return fmt.Errorf("invalid stapled OCSP response: %s", err.Error())
+	}
+	if staple.Status != ocsp.Good {
+		return fmt.Errorf("stapled OCSP status is not good (%d)", staple.Status)
+	}
+	if !staple.NextUpdate.IsZero() && staple.NextUpdate.Before(time.Now()) {
+		return errors.New("stapled OCSP response is stale")
+	}
+	return nil
+}
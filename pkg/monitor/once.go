@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+type onceCheckJSON struct {
+	Name       string  `json:"name"`
+	URL        string  `json:"url,omitempty"`
+	Status     string  `json:"status"`
+	Latency    float64 `json:"latency_seconds"`
+	StatusCode int     `json:"status_code,omitempty"`
+	Failure    string  `json:"failure,omitempty"`
+}
+
+const onceStatusSkipped = "skipped"
+
+type onceSummaryJSON struct {
+	OK     bool            `json:"ok"`
+	Checks []onceCheckJSON `json:"checks"`
+}
+
+func RunOnce(config Config, summaryPath string) (int, error) {
+	if !config.prepared {
+		if err := prepareConfig(&config); err != nil {
+			return 1, err
+		}
+	}
+
+	checks := make([]onceCheckJSON, len(config.Items))
+
+	var wg sync.WaitGroup
+	for idx, conf := range config.Items {
+		if conf.Type == checkTypeHeartbeat {
+			checks[idx] = onceCheckJSON{Name: conf.Name, Status: onceStatusSkipped}
+			continue
+		}
+		wg.Add(1)
+		go func(idx int, conf CheckConfig) {
+			defer wg.Done()
+			start := time.Now()
+			result := newProber(conf, nil).probe(context.Background(), conf)
+			elapsed := time.Since(start)
+			result = checkResponseTime(conf, result, elapsed)
+			status := checkStatusToString(checkStatusAlarm)
+			if result.ok {
+				status = checkStatusToString(checkStatusOk)
+			}
+			checks[idx] = onceCheckJSON{
+				Name:       conf.Name,
+				URL:        redactURL(conf.URL),
+				Status:     status,
+				Latency:    elapsed.Seconds(),
+				StatusCode: result.statusCode,
+			}
+			if !result.ok {
+				checks[idx].Failure = result.detail
+			}
+		}(idx, conf)
+	}
+	wg.Wait()
+
+	summary := onceSummaryJSON{OK: true, Checks: checks}
+	for _, check := range checks {
+		slog.Info("check", "name", check.Name, "status", check.Status)
+		if check.Status == checkStatusToString(checkStatusAlarm) {
+			summary.OK = false
+		}
+	}
+
+	if len(summaryPath) > 0 {
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return 1, err
+		}
+		data = append(data, '\n')
+		if summaryPath == "-" {
+			os.Stdout.Write(data)
+		} else if err := os.WriteFile(summaryPath, data, 0644); err != nil {
+			return 1, err
+		}
+	}
+
+	if summary.OK {
+		return 0, nil
+	}
+	return 1, nil
+}
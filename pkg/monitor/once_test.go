@@ -0,0 +1,22 @@
+package monitor
+
+import "testing"
+
+func TestRunOncePreparesConfig(t *testing.T) {
+	check := execCheck("script")
+	check.Command = "echo hello"
+	check.OutputMatch = "goodbye"
+	config := Config{Items: []CheckConfig{check}}
+	code, err := RunOnce(config, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 1 {
+		t.Fatalf("RunOnce = %d, want 1 for output that doesn't match", code)
+	}
+
+	config.Items[0].OutputMatch = "("
+	if _, err := RunOnce(config, ""); err == nil {
+		t.Fatal("RunOnce accepted an invalid OutputMatch")
+	}
+}
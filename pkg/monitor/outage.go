@@ -0,0 +1,125 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+)
+
+func detectOutage(config Config, registry *statusRegistry, in <-chan statusChange, out chan<- statusChange, errs chan<- error) {
+	defer reportPanic(errs)
+	defer close(out)
+
+	statuses := make(map[string]checkStatus)
+	suppressed := make(map[string]statusChange)
+	var pending []statusChange
+	var flush <-chan time.Time
+	var outage bool
+
+	alarmed := func() (int, int) {
+		names := registry.names()
+		count := 0
+		for _, name := range names {
+			if statuses[name] == checkStatusAlarm {
+				count++
+			}
+		}
+		return count, len(names)
+	}
+
+	isOutage := func() bool {
+		count, total := alarmed()
+		return total > 0 && float64(count) >= config.OutageThreshold*float64(total)
+	}
+
+	for {
+		select {
+		case change, ok := <-in:
+			if !ok {
+				for _, held := range pending {
+					out <- held
+				}
+				return
+			}
+			statuses[change.name] = change.to
+
+			if outage {
+				if change.to == checkStatusAlarm {
+					suppressed[change.name] = change
+				} else if held, ok := suppressed[change.name]; ok {
+					delete(suppressed, change.name)
+					if change.to != checkStatusOk {
+						out <- held
+						out <- change
+					}
+				} else {
+					out <- change
+				}
+				if !isOutage() {
+					outage = false
+					out <- statusChange{
+						name:   "itsalive",
+						time:   time.Now(),
+						from:   checkStatusAlarm,
+						to:     checkStatusOk,
+						detail: "monitor network recovered",
+					}
+					for _, held := range suppressed {
+						out <- held
+					}
+					suppressed = make(map[string]statusChange)
+				}
+				continue
+			}
+
+			if change.to == checkStatusAlarm {
+				pending = append(pending, change)
+				if flush == nil {
+					flush = time.After(config.OutageWindow.Duration)
+				}
+				continue
+			}
+
+			var recovered bool
+			for idx, held := range pending {
+				if held.name == change.name {
+					pending = append(pending[:idx], pending[idx+1:]...)
+					if change.to != checkStatusOk {
+						out <- held
+					} else {
+						recovered = true
+					}
+					break
+				}
+			}
+			if !recovered {
+				out <- change
+			}
+
+		case <-flush:
+			flush = nil
+			if isOutage() {
+				count, total := alarmed()
+				outage = true
+				for _, held := range pending {
+					suppressed[held.name] = held
+				}
+				out <- statusChange{
+					name: "itsalive",
+					time: time.Now(),
+					from: checkStatusOk,
+					to:   checkStatusAlarm,
+					detail: fmt.Sprintf(
+						"possible monitor network issue: %d of %d checks failing",
+						count,
+						total,
+					),
+				}
+			} else {
+				for _, held := range pending {
+					out <- held
+				}
+			}
+			pending = nil
+		}
+	}
+}
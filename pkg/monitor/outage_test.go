@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+type outageHarness struct {
+	t        *testing.T
+	registry *statusRegistry
+	in       chan statusChange
+	out      chan statusChange
+}
+
+func newOutageHarness(t *testing.T, names ...string) *outageHarness {
+	registry := newStatusRegistry(10)
+	for _, name := range names {
+		registry.register(CheckConfig{Name: name})
+	}
+	h := &outageHarness{
+		t:        t,
+		registry: registry,
+		in:       make(chan statusChange),
+		out:      make(chan statusChange, 100),
+	}
+	config := Config{OutageThreshold: 0.5, OutageWindow: Duration{50 * time.Millisecond}}
+	go detectOutage(config, registry, h.in, h.out, make(chan error, 1))
+	t.Cleanup(func() { close(h.in) })
+	return h
+}
+
+func (h *outageHarness) send(name string, from, to checkStatus) {
+	h.in <- statusChange{name: name, from: from, to: to, time: time.Now()}
+}
+
+func (h *outageHarness) expect(name string, to checkStatus) {
+	h.t.Helper()
+	select {
+	case change := <-h.out:
+		if change.name != name || change.to != to {
+			h.t.Fatalf("got %s -> %s, want %s -> %s",
+				change.name, checkStatusToString(change.to), name, checkStatusToString(to))
+		}
+	case <-time.After(time.Second):
+		h.t.Fatalf("timed out waiting for %s -> %s", name, checkStatusToString(to))
+	}
+}
+
+func (h *outageHarness) expectNothing() {
+	h.t.Helper()
+	select {
+	case change := <-h.out:
+		h.t.Fatalf("unexpected change %s -> %s", change.name, checkStatusToString(change.to))
+	case <-time.After(150 * time.Millisecond):
+	}
+}
+
+func TestDetectOutageCollapsesAlarms(t *testing.T) {
+	h := newOutageHarness(t, "a", "b", "c", "d")
+	h.send("a", checkStatusOk, checkStatusAlarm)
+	h.send("b", checkStatusOk, checkStatusAlarm)
+	h.send("c", checkStatusOk, checkStatusAlarm)
+	h.expect("itsalive", checkStatusAlarm)
+	h.expectNothing()
+
+	h.send("a", checkStatusAlarm, checkStatusOk)
+	h.expectNothing()
+	h.send("b", checkStatusAlarm, checkStatusOk)
+	h.expect("itsalive", checkStatusOk)
+	h.expect("c", checkStatusAlarm)
+	h.expectNothing()
+}
+
+func TestDetectOutageBelowThreshold(t *testing.T) {
+	h := newOutageHarness(t, "a", "b", "c", "d")
+	h.send("a", checkStatusOk, checkStatusAlarm)
+	h.expect("a", checkStatusAlarm)
+	h.send("a", checkStatusAlarm, checkStatusOk)
+	h.expect("a", checkStatusOk)
+}
+
+func TestDetectOutageRecoveryInsideWindow(t *testing.T) {
+	h := newOutageHarness(t, "a", "b", "c", "d")
+	h.send("a", checkStatusOk, checkStatusAlarm)
+	h.send("a", checkStatusAlarm, checkStatusOk)
+	h.expectNothing()
+}
+
+func TestDetectOutageDegradedInsideWindow(t *testing.T) {
+	h := newOutageHarness(t, "a", "b", "c", "d")
+	h.send("a", checkStatusOk, checkStatusAlarm)
+	h.send("a", checkStatusAlarm, checkStatusDegraded)
+	h.expect("a", checkStatusAlarm)
+	h.expect("a", checkStatusDegraded)
+	h.expectNothing()
+}
+
+func TestDetectOutageFollowsRegisteredChecks(t *testing.T) {
+	h := newOutageHarness(t, "a", "b", "c", "d")
+	h.registry.unregister("c")
+	h.registry.unregister("d")
+	h.send("a", checkStatusOk, checkStatusAlarm)
+	h.expect("itsalive", checkStatusAlarm)
+
+	h.registry.register(CheckConfig{Name: "e"})
+	h.registry.register(CheckConfig{Name: "f"})
+	h.registry.register(CheckConfig{Name: "g"})
+	h.send("b", checkStatusOk, checkStatusOk)
+	h.expect("b", checkStatusOk)
+	h.expect("itsalive", checkStatusOk)
+	h.expect("a", checkStatusAlarm)
+}
@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+type pagerDutyPayload struct {
+	Summary  string `json:"summary"`
+	Source   string `json:"source"`
+	Severity string `json:"severity"`
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyNotifier struct {
+	sync.Mutex
+	client     *http.Client
+	url        string
+	routingKey string
+	triggered  map[string]bool
+}
+
+func newPagerDutyNotifier(config NotifierConfig) *pagerDutyNotifier {
+	n := &pagerDutyNotifier{
+		client:     &http.Client{Timeout: 10 * time.Second},
+		url:        pagerDutyEventsURL,
+		routingKey: config.RoutingKey,
+		triggered:  make(map[string]bool),
+	}
+	if utf8.RuneCountInString(config.URL) > 0 {
+		n.url = config.URL
+	}
+	return n
+}
+
+func pagerDutyDedupKey(name string) string {
+	return "itsalive-" + name
+}
+
+func newPagerDutyEvent(routingKey string, change statusChange, triggered bool) (pagerDutyEvent, bool) {
+	event := pagerDutyEvent{
+		RoutingKey: routingKey,
+		DedupKey:   pagerDutyDedupKey(change.name),
+	}
+	switch {
+	case change.to == checkStatusAlarm:
+		severity := change.severity
+		if utf8.RuneCountInString(severity) == 0 {
+			severity = severityCritical
+		}
+		summary := fmt.Sprintf("%s is ALARM", change.name)
+		if utf8.RuneCountInString(change.detail) > 0 {
+			summary += ": " + change.detail
+		}
+		source := change.url
+		if utf8.RuneCountInString(source) == 0 {
+			source = change.name
+		}
+		event.EventAction = "trigger"
+		event.Payload = &pagerDutyPayload{Summary: summary, Source: source, Severity: severity}
+	case change.to == checkStatusUnknown || change.flapping:
+		return event, false
+	case triggered || change.from == checkStatusAlarm:
+		event.EventAction = "resolve"
+	default:
+		return event, false
+	}
+	return event, true
+}
+
+func (n *pagerDutyNotifier) Notify(change statusChange) error {
+	n.Lock()
+	defer n.Unlock()
+	event, ok := newPagerDutyEvent(n.routingKey, change, n.triggered[pagerDutyDedupKey(change.name)])
+	if !ok {
+		return nil
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer drainBody(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("pagerduty returned status %d", resp.StatusCode)
+	}
+	if event.EventAction == "trigger" {
+		n.triggered[event.DedupKey] = true
+	} else {
+		delete(n.triggered, event.DedupKey)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestNewPagerDutyEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    statusChange
+		triggered bool
+		action    string
+	}{
+		{"alarm triggers", statusChange{from: checkStatusOk, to: checkStatusAlarm}, false, "trigger"},
+		{"reminder retriggers", statusChange{from: checkStatusAlarm, to: checkStatusAlarm}, true, "trigger"},
+		{"recovery resolves", statusChange{from: checkStatusAlarm, to: checkStatusOk}, false, "resolve"},
+		{"degraded resolves an alarm", statusChange{from: checkStatusAlarm, to: checkStatusDegraded}, false, "resolve"},
+		{"stabilized ok resolves an open incident", statusChange{from: checkStatusOk, to: checkStatusOk}, true, "resolve"},
+		{"ok without an incident is ignored", statusChange{from: checkStatusOk, to: checkStatusOk}, false, ""},
+		{"flapping recovery is not settled", statusChange{from: checkStatusAlarm, to: checkStatusOk, flapping: true}, true, ""},
+		{"unknown is ignored", statusChange{from: checkStatusAlarm, to: checkStatusUnknown}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change.name = "api"
+			event, ok := newPagerDutyEvent("key", tt.change, tt.triggered)
+			if len(tt.action) == 0 {
+				if ok {
+					t.Fatalf("expected no event, got %q", event.EventAction)
+				}
+				return
+			}
+			if !ok || event.EventAction != tt.action {
+				t.Fatalf("got %q (%v), want %q", event.EventAction, ok, tt.action)
+			}
+			if event.DedupKey != "itsalive-api" {
+				t.Fatalf("DedupKey = %q", event.DedupKey)
+			}
+		})
+	}
+}
+
+func TestPagerDutyResolvesAfterFlapping(t *testing.T) {
+	var mu sync.Mutex
+	var actions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("invalid event: %s", err)
+		}
+		mu.Lock()
+		actions = append(actions, event.EventAction)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	notifier := newPagerDutyNotifier(NotifierConfig{RoutingKey: "key", URL: server.URL})
+	changes := []statusChange{
+		{name: "api", from: checkStatusOk, to: checkStatusAlarm, flapping: true},
+		{name: "api", from: checkStatusOk, to: checkStatusOk},
+		{name: "api", from: checkStatusOk, to: checkStatusOk},
+	}
+	for _, change := range changes {
+		if err := notifier.Notify(change); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(actions) != 2 || actions[0] != "trigger" || actions[1] != "resolve" {
+		t.Fatalf("actions = %v, want [trigger resolve]", actions)
+	}
+}
@@ -0,0 +1,380 @@
+package monitor
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+type changeRing struct {
+	changes []statusChange
+	next    int
+	full    bool
+}
+
+func newChangeRing(size int) *changeRing {
+	return &changeRing{changes: make([]statusChange, size)}
+}
+
+func (r *changeRing) push(change statusChange) {
+	r.changes[r.next] = change
+	r.next = (r.next + 1) % len(r.changes)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *changeRing) items() []statusChange {
+	if !r.full {
+		return append([]statusChange(nil), r.changes[:r.next]...)
+	}
+	items := make([]statusChange, 0, len(r.changes))
+	items = append(items, r.changes[r.next:]...)
+	return append(items, r.changes[:r.next]...)
+}
+
+type checkState struct {
+	name        string
+	url         string
+	status      checkStatus
+	changed     time.Time
+	checked     time.Time
+	maintenance []MaintenanceWindow
+	silenced    time.Time
+	token       string
+	dependsOn   string
+	registered  time.Time
+	pinged      time.Time
+	uptime      uptimeTracker
+	suppressed  bool
+	changes     *changeRing
+	replicas    []replicaResult
+}
+
+type statusRegistry struct {
+	sync.Mutex
+	historySize int
+	checks      map[string]*checkState
+	restored    map[string]checkSnapshot
+	stateFile   string
+	downtimeLog string
+}
+
+func newStatusRegistry(historySize int) *statusRegistry {
+	return &statusRegistry{
+		historySize: historySize,
+		checks:      make(map[string]*checkState),
+	}
+}
+
+func (r *statusRegistry) register(config CheckConfig) {
+	r.Lock()
+	defer r.Unlock()
+	if state, ok := r.checks[config.Name]; ok {
+		state.url = redactURL(config.URL)
+		state.maintenance = config.MaintenanceWindows
+		state.token = config.Token
+		state.dependsOn = config.DependsOn
+		return
+	}
+	state := &checkState{
+		name:        config.Name,
+		url:         redactURL(config.URL),
+		maintenance: config.MaintenanceWindows,
+		token:       config.Token,
+		dependsOn:   config.DependsOn,
+		registered:  time.Now(),
+		changes:     newChangeRing(r.historySize),
+	}
+	if snapshot, ok := r.restored[config.Name]; ok {
+		state.status = stringToCheckStatus(snapshot.Status)
+		state.changed = snapshot.Changed
+		for _, item := range snapshot.History {
+			state.changes.push(changeFromJSON(item))
+		}
+	}
+	r.checks[config.Name] = state
+}
+
+func (r *statusRegistry) unregister(name string) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.checks, name)
+}
+
+func (r *statusRegistry) restore(snapshots map[string]checkSnapshot) {
+	r.Lock()
+	defer r.Unlock()
+	r.restored = snapshots
+}
+
+func (r *statusRegistry) persistTo(path string) {
+	r.Lock()
+	defer r.Unlock()
+	r.stateFile = path
+}
+
+func (r *statusRegistry) logDowntimeTo(path string) {
+	r.Lock()
+	defer r.Unlock()
+	r.downtimeLog = path
+}
+
+func (r *statusRegistry) downtimes(name string) ([]downtimeRecord, bool, error) {
+	r.Lock()
+	path := r.downtimeLog
+	r.Unlock()
+	if len(path) == 0 {
+		return nil, false, nil
+	}
+	records, err := readDowntime(path, name)
+	return records, true, err
+}
+
+func (r *statusRegistry) snapshot() map[string]checkSnapshot {
+	r.Lock()
+	defer r.Unlock()
+	return r.snapshotLocked()
+}
+
+func (r *statusRegistry) snapshotLocked() map[string]checkSnapshot {
+	snapshots := make(map[string]checkSnapshot, len(r.checks))
+	for name, state := range r.checks {
+		if state.status == checkStatusUnknown {
+			continue
+		}
+		changes := state.changes.items()
+		history := make([]changeJSON, 0, len(changes))
+		for _, change := range changes {
+			history = append(history, changeToJSON(change))
+		}
+		snapshots[name] = checkSnapshot{
+			Status:  checkStatusToString(state.status),
+			Changed: state.changed,
+			History: history,
+		}
+	}
+	return snapshots
+}
+
+func (r *statusRegistry) status(name string) checkStatus {
+	r.Lock()
+	defer r.Unlock()
+	state, ok := r.checks[name]
+	if !ok {
+		return checkStatusUnknown
+	}
+	return state.status
+}
+
+func (r *statusRegistry) recordChange(change statusChange) {
+	r.Lock()
+	defer r.Unlock()
+	state, ok := r.checks[change.name]
+	if !ok {
+		return
+	}
+	state.changes.push(change)
+	if change.from == change.to || change.to == state.status {
+		return
+	}
+	state.status = change.to
+	state.changed = change.time
+	if change.downtime > 0 {
+		state.uptime.recordDowntime(change.time, change.downtime)
+	}
+
+	if len(r.downtimeLog) > 0 && change.downtime > 0 {
+		if err := appendDowntime(r.downtimeLog, newDowntimeRecord(change)); err != nil {
+			slog.Error("failed to log downtime", "error", err)
+		}
+	}
+
+	if len(r.stateFile) > 0 {
+		if err := saveState(r.stateFile, r.snapshotLocked()); err != nil {
+			slog.Error("failed to save state", "error", err)
+		}
+	}
+}
+
+func (r *statusRegistry) ping(token string, at time.Time) bool {
+	r.Lock()
+	defer r.Unlock()
+	for _, state := range r.checks {
+		if len(state.token) > 0 && state.token == token {
+			state.pinged = at
+			return true
+		}
+	}
+	return false
+}
+
+func (r *statusRegistry) lastPing(name string) (time.Time, time.Time, bool) {
+	r.Lock()
+	defer r.Unlock()
+	state, ok := r.checks[name]
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	return state.pinged, state.registered, true
+}
+
+func (r *statusRegistry) silence(name string, until time.Time) bool {
+	r.Lock()
+	defer r.Unlock()
+	state, ok := r.checks[name]
+	if !ok {
+		return false
+	}
+	state.silenced = until
+	return true
+}
+
+func (r *statusRegistry) silenced(name string, now time.Time) bool {
+	r.Lock()
+	defer r.Unlock()
+	state, ok := r.checks[name]
+	if !ok {
+		return false
+	}
+	return now.Before(state.silenced) || inMaintenance(state.maintenance, now)
+}
+
+func (r *statusRegistry) recordCheck(name string, healthy bool, at time.Time) {
+	r.Lock()
+	defer r.Unlock()
+	state, ok := r.checks[name]
+	if !ok {
+		return
+	}
+	state.checked = at
+	state.uptime.record(at, healthy)
+}
+
+func (r *statusRegistry) recordReplicas(name string, replicas []replicaResult) {
+	r.Lock()
+	defer r.Unlock()
+	state, ok := r.checks[name]
+	if !ok {
+		return
+	}
+	state.replicas = replicas
+}
+
+func (r *statusRegistry) replicas(name string) ([]replicaResult, bool) {
+	r.Lock()
+	defer r.Unlock()
+	state, ok := r.checks[name]
+	if !ok {
+		return nil, false
+	}
+	return state.replicas, true
+}
+
+func (r *statusRegistry) history(name string) ([]statusChange, bool) {
+	r.Lock()
+	defer r.Unlock()
+	state, ok := r.checks[name]
+	if !ok {
+		return nil, false
+	}
+	return state.changes.items(), true
+}
+
+func (r *statusRegistry) states() []checkState {
+	r.Lock()
+	defer r.Unlock()
+	states := make([]checkState, 0, len(r.checks))
+	for _, state := range r.checks {
+		states = append(states, checkState{
+			name:       state.name,
+			url:        state.url,
+			status:     state.status,
+			changed:    state.changed,
+			checked:    state.checked,
+			uptime:     state.uptime,
+			suppressed: r.suppressedLocked(state.name),
+		})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].name < states[j].name })
+	return states
+}
+
+func (r *statusRegistry) names() []string {
+	r.Lock()
+	defer r.Unlock()
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	return names
+}
+
+type changeJSON struct {
+	Name     string    `json:"name"`
+	URL      string    `json:"url"`
+	Time     time.Time `json:"time"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Detail   string    `json:"detail,omitempty"`
+	Source   string    `json:"source,omitempty"`
+	Severity string    `json:"severity,omitempty"`
+	Cause    string    `json:"cause,omitempty"`
+	Latency  float64   `json:"latency_seconds,omitempty"`
+	Downtime float64   `json:"downtime_seconds,omitempty"`
+}
+
+func changeToJSON(change statusChange) changeJSON {
+	return changeJSON{
+		Name:     change.name,
+		URL:      change.url,
+		Time:     change.time,
+		From:     checkStatusToString(change.from),
+		To:       checkStatusToString(change.to),
+		Detail:   change.detail,
+		Source:   change.source,
+		Severity: change.severity,
+		Cause:    change.cause,
+		Latency:  change.elapsed.Seconds(),
+		Downtime: change.downtime.Seconds(),
+	}
+}
+
+func changeFromJSON(item changeJSON) statusChange {
+	return statusChange{
+		name:     item.Name,
+		url:      item.URL,
+		time:     item.Time,
+		from:     stringToCheckStatus(item.From),
+		to:       stringToCheckStatus(item.To),
+		detail:   item.Detail,
+		source:   item.Source,
+		severity: item.Severity,
+		cause:    item.Cause,
+		elapsed:  time.Duration(item.Latency * float64(time.Second)),
+		downtime: time.Duration(item.Downtime * float64(time.Second)),
+	}
+}
+
+type replicaJSON struct {
+	URL     string    `json:"url"`
+	Status  string    `json:"status"`
+	Latency float64   `json:"latency_seconds"`
+	Detail  string    `json:"detail,omitempty"`
+	Checked time.Time `json:"checked"`
+}
+
+func replicaToJSON(replica replicaResult) replicaJSON {
+	status := checkStatusToString(checkStatusAlarm)
+	if replica.ok {
+		status = checkStatusToString(checkStatusOk)
+	}
+	return replicaJSON{
+		URL:     replica.url,
+		Status:  status,
+		Latency: replica.elapsed.Seconds(),
+		Detail:  replica.detail,
+		Checked: replica.checked,
+	}
+}
@@ -0,0 +1,101 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type replicaResult struct {
+	url     string
+	ok      bool
+	elapsed time.Duration
+	detail  string
+	checked time.Time
+}
+
+type vantagePoint struct {
+	label  string
+	url    string
+	client *http.Client
+}
+
+func newProxyClient(config CheckConfig, proxy *url.URL) *http.Client {
+	client := newHTTPClient(config, "")
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	transport.Proxy = http.ProxyURL(proxy)
+	client.Transport = transport
+	return client
+}
+
+func newVantagePoints(config CheckConfig, client *http.Client) []vantagePoint {
+	var points []vantagePoint
+	for _, replica := range config.URLs {
+		points = append(points, vantagePoint{label: redactURL(replica), url: replica, client: client})
+	}
+	for _, proxy := range config.Proxies {
+		parsed, err := url.Parse(proxy)
+		if err != nil {
+			continue
+		}
+		points = append(points, vantagePoint{
+			label:  redactURL(proxy),
+			url:    config.URL,
+			client: newProxyClient(config, parsed),
+		})
+	}
+	return points
+}
+
+func probeReplicas(ctx context.Context, points []vantagePoint, config CheckConfig) probeResult {
+	replicas := make([]replicaResult, len(points))
+
+	var wg sync.WaitGroup
+	for idx, point := range points {
+		wg.Add(1)
+		go func(idx int, point vantagePoint) {
+			defer wg.Done()
+			replica := config
+			replica.URL = point.url
+			start := time.Now()
+			result := probeHTTP(ctx, point.client, replica)
+			replicas[idx] = replicaResult{
+				url:     point.label,
+				ok:      result.ok,
+				elapsed: time.Since(start),
+				detail:  result.detail,
+				checked: start,
+			}
+		}(idx, point)
+	}
+	wg.Wait()
+
+	var failing []string
+	for _, replica := range replicas {
+		if !replica.ok {
+			failing = append(failing, replica.url)
+		}
+	}
+
+	quorum := config.Quorum
+	if quorum == 0 {
+		quorum = len(replicas)
+	}
+	result := probeResult{ok: len(failing) < quorum, replicas: replicas}
+	if len(failing) > 0 {
+		result.detail = fmt.Sprintf(
+			"%d of %d replicas failing: %s",
+			len(failing),
+			len(replicas),
+			strings.Join(failing, ", "),
+		)
+	}
+	return result
+}
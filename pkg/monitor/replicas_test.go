@@ -0,0 +1,54 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestProbeReplicasChecksEveryReplica(t *testing.T) {
+	var hits [3]int32
+	var urls []string
+	for idx := range hits {
+		status := http.StatusOK
+		if idx == 2 {
+			status = http.StatusInternalServerError
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(idx, status int) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&hits[idx], 1)
+				w.WriteHeader(status)
+			}
+		}(idx, status)))
+		defer server.Close()
+		urls = append(urls, server.URL)
+	}
+
+	config := validCheckConfig()
+	config.URL = ""
+	config.URLs = urls
+	config.Quorum = 2
+	points := newVantagePoints(config, newHTTPClient(config, ""))
+
+	for cycle := 1; cycle <= 2; cycle++ {
+		result := probeReplicas(context.Background(), points, config)
+		if !result.ok {
+			t.Fatalf("cycle %d: one failing replica broke a quorum of 2: %s", cycle, result.detail)
+		}
+		if len(result.replicas) != len(urls) {
+			t.Fatalf("cycle %d: got %d replica results, want %d", cycle, len(result.replicas), len(urls))
+		}
+		for idx, replica := range result.replicas {
+			if replica.url != urls[idx] || replica.ok != (idx != 2) {
+				t.Errorf("cycle %d: replica %d = %s ok=%v", cycle, idx, replica.url, replica.ok)
+			}
+		}
+		for idx := range hits {
+			if got := atomic.LoadInt32(&hits[idx]); got != int32(cycle) {
+				t.Errorf("cycle %d: replica %d probed %d times", cycle, idx, got)
+			}
+		}
+	}
+}
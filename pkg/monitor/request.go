@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+var bodyTemplateFuncs = template.FuncMap{
+	"now":   time.Now,
+	"nonce": nonce,
+}
+
+func nonce() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+func parseBodyTemplate(body string) (*template.Template, error) {
+	return template.New("body").Funcs(bodyTemplateFuncs).Parse(body)
+}
+
+var knownMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+func isKnownMethod(method string) bool {
+	for _, known := range knownMethods {
+		if method == known {
+			return true
+		}
+	}
+	return false
+}
+
+func newRequest(config CheckConfig) (*http.Request, error) {
+	var req *http.Request
+	var err error
+	if config.bodyTemplate == nil {
+		req, err = http.NewRequest(config.Method, config.URL, nil)
+	} else {
+		var body bytes.Buffer
+		if err := config.bodyTemplate.Execute(&body, nil); err != nil {
+			return nil, err
+		}
+		req, err = http.NewRequest(config.Method, config.URL, &body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range config.Headers {
+		if http.CanonicalHeaderKey(key) == "Host" {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+
+	if utf8.RuneCountInString(config.BasicAuthUser) > 0 {
+		req.SetBasicAuth(config.BasicAuthUser, config.BasicAuthPassword)
+	} else if utf8.RuneCountInString(config.BearerToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+config.BearerToken)
+	}
+	return req, nil
+}
+
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return parsed.Redacted()
+}
@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+type RouteConfig struct {
+	NamePrefix string
+	Tags       []string
+	Channel    string
+	BotName    string
+	IconEmoji  string
+}
+
+func validateRouteConfig(config RouteConfig) error {
+	if utf8.RuneCountInString(config.NamePrefix) == 0 && len(config.Tags) == 0 {
+		return errors.New("route needs NamePrefix or Tags")
+	}
+	if utf8.RuneCountInString(config.Channel) == 0 &&
+		utf8.RuneCountInString(config.BotName) == 0 &&
+		utf8.RuneCountInString(config.IconEmoji) == 0 {
+		return errors.New("route needs Channel, BotName or IconEmoji")
+	}
+	return nil
+}
+
+func (r RouteConfig) matches(config CheckConfig) bool {
+	if utf8.RuneCountInString(r.NamePrefix) > 0 && !strings.HasPrefix(config.Name, r.NamePrefix) {
+		return false
+	}
+	if len(r.Tags) > 0 && !matchesTags(r.Tags, config.Tags) {
+		return false
+	}
+	return true
+}
+
+func applyRoutes(routes []RouteConfig, config *CheckConfig) {
+	for _, route := range routes {
+		if !route.matches(*config) {
+			continue
+		}
+		if utf8.RuneCountInString(config.SlackChannel) == 0 {
+			config.SlackChannel = route.Channel
+		}
+		if utf8.RuneCountInString(config.SlackBotName) == 0 {
+			config.SlackBotName = route.BotName
+		}
+		if utf8.RuneCountInString(config.SlackIconEmoji) == 0 {
+			config.SlackIconEmoji = route.IconEmoji
+		}
+		return
+	}
+}
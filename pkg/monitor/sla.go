@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nlopes/slack"
+)
+
+type slaWindowJSON struct {
+	Uptime   *float64 `json:"uptime,omitempty"`
+	Downtime float64  `json:"downtime_seconds"`
+}
+
+type slaJSON struct {
+	Name    string                   `json:"name"`
+	URL     string                   `json:"url"`
+	Windows map[string]slaWindowJSON `json:"windows"`
+}
+
+func stateToSLA(state checkState, now time.Time) slaJSON {
+	item := slaJSON{
+		Name:    state.name,
+		URL:     state.url,
+		Windows: make(map[string]slaWindowJSON, len(slaWindows)),
+	}
+	for _, sla := range slaWindows {
+		window := slaWindowJSON{Downtime: state.uptime.downtime(now, sla.window).Seconds()}
+		if uptime, ok := state.uptime.ratio(now, sla.window); ok {
+			window.Uptime = &uptime
+		}
+		item.Windows[sla.name] = window
+	}
+	return item
+}
+
+func slaHandler(registry *statusRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		states := registry.states()
+		items := make([]slaJSON, 0, len(states))
+		for _, state := range states {
+			if name := r.URL.Query().Get("name"); len(name) > 0 && name != state.name {
+				continue
+			}
+			items = append(items, stateToSLA(state, now))
+		}
+		writeJSON(w, items)
+	}
+}
+
+type DigestConfig struct {
+	Days    []string
+	At      string
+	Token   string
+	Channel string
+	BotName string
+}
+
+func validateDigestConfig(config DigestConfig) error {
+	for _, day := range config.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("unknown day %q", day)
+		}
+	}
+	if _, err := parseClock(config.At); err != nil {
+		return fmt.Errorf("invalid At: %s", err.Error())
+	}
+	if utf8.RuneCountInString(config.Token) == 0 {
+		return errors.New("empty Token")
+	}
+	if utf8.RuneCountInString(config.Channel) == 0 {
+		return errors.New("empty Channel")
+	}
+	return nil
+}
+
+func (c DigestConfig) next(now time.Time) time.Time {
+	minutes, _ := parseClock(c.At)
+	window := MaintenanceWindow{Days: c.Days}
+	for offset := 0; offset <= 7; offset++ {
+		day := now.AddDate(0, 0, offset)
+		at := time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, now.Location())
+		if at.After(now) && window.onDay(at.Weekday()) {
+			return at
+		}
+	}
+	return now.Add(24 * time.Hour)
+}
+
+func formatPercent(ratio float64, ok bool) string {
+	if !ok {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.2f%%", ratio*100)
+}
+
+func formatDigest(states []checkState, now time.Time) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "*itsalive availability report* (%s)\n", now.Format("2006-01-02"))
+	for _, state := range states {
+		fmt.Fprintf(&text, "*%s*:", state.name)
+		for idx, sla := range slaWindows {
+			if idx > 0 {
+				text.WriteString(",")
+			}
+			uptime, ok := state.uptime.ratio(now, sla.window)
+			fmt.Fprintf(&text, " %s %s", sla.name, formatPercent(uptime, ok))
+		}
+		if downtime := state.uptime.downtime(now, 7*24*time.Hour); downtime > 0 {
+			fmt.Fprintf(&text, " (down %s this week)", downtime.Truncate(time.Second))
+		}
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+func runDigest(ctx context.Context, config DigestConfig, registry *statusRegistry, elector *leaderElector, errs chan<- error) {
+	defer reportPanic(errs)
+
+	api := slack.New(config.Token)
+	for {
+		at := config.next(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(at)):
+		}
+		if !elector.isLeader() {
+			continue
+		}
+
+		params := slack.PostMessageParameters{Username: config.BotName}
+		text := formatDigest(registry.states(), time.Now())
+		if _, _, err := api.PostMessage(config.Channel, text, params); err != nil {
+			slog.Error("failed to post digest", "error", err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nlopes/slack"
+)
+
+const (
+	slackRetryAttempts = 5
+	slackRetryBackoff  = time.Second
+)
+
+func formatSlackMessage(botName string, change statusChange) slack.PostMessageParameters {
+	label := strings.ToUpper(checkStatusToString(change.to))
+	if !change.certExpiry.IsZero() {
+		label = "CERTIFICATE EXPIRING"
+	} else if change.flapping {
+		label = "FLAPPING"
+	}
+
+	var text string
+	if utf8.RuneCountInString(change.url) == 0 {
+		text = fmt.Sprintf("%s *%s*", change.name, label)
+	} else {
+		text = fmt.Sprintf("%s (%s) *%s*", change.name, change.url, label)
+	}
+	if utf8.RuneCountInString(change.source) > 0 {
+		text += " from " + change.source
+	}
+	if change.downtime > 0 {
+		text += fmt.Sprintf(", recovered after %s", change.downtime.Truncate(time.Second))
+	}
+	if utf8.RuneCountInString(change.detail) > 0 {
+		text += "\n" + change.detail
+	}
+	if change.message != nil {
+		if rendered, err := renderMessage(change, label); err != nil {
+			slog.Error("failed to render message template", "name", change.name, "error", err)
+		} else {
+			text = rendered
+		}
+	}
+	if utf8.RuneCountInString(change.botName) > 0 {
+		botName = change.botName
+	}
+	messageParams := slack.PostMessageParameters{Username: botName, IconEmoji: change.iconEmoji}
+	attach := slack.Attachment{}
+	attach.Fallback = text
+	attach.Text = text
+	attach.MarkdownIn = []string{"text"}
+	switch {
+	case !change.certExpiry.IsZero() || change.flapping:
+		attach.Color = "warning"
+	case change.to == checkStatusOk:
+		attach.Color = "good"
+	case change.to == checkStatusDegraded:
+		attach.Color = "warning"
+	case change.to == checkStatusAlarm:
+		attach.Color = "danger"
+		if change.severity == severityWarning {
+			attach.Color = "warning"
+		}
+	}
+	if utf8.RuneCountInString(change.cause) > 0 && change.to != checkStatusOk {
+		attach.Fields = append(attach.Fields, slack.AttachmentField{Title: "Cause", Value: change.cause, Short: true})
+	}
+	if change.elapsed > 0 {
+		attach.Fields = append(attach.Fields, slack.AttachmentField{
+			Title: "Latency",
+			Value: change.elapsed.Truncate(time.Millisecond).String(),
+			Short: true,
+		})
+	}
+	messageParams.Attachments = []slack.Attachment{attach}
+	return messageParams
+}
+
+type slackNotifier struct {
+	api     *slack.Client
+	channel string
+	botName string
+}
+
+func newSlackNotifier(config NotifierConfig) *slackNotifier {
+	return &slackNotifier{
+		api:     slack.New(config.Token),
+		channel: config.Channel,
+		botName: config.BotName,
+	}
+}
+
+func (n *slackNotifier) channelFor(change statusChange) string {
+	if utf8.RuneCountInString(change.channel) > 0 {
+		return change.channel
+	}
+	return n.channel
+}
+
+func (n *slackNotifier) Notify(change statusChange) error {
+	return n.post(n.channelFor(change), formatSlackMessage(n.botName, change))
+}
+
+type slackBatchKey struct {
+	channel   string
+	botName   string
+	iconEmoji string
+}
+
+func (n *slackNotifier) NotifyBatch(changes []statusChange) error {
+	var keys []slackBatchKey
+	grouped := make(map[slackBatchKey][]statusChange)
+	for _, change := range changes {
+		key := slackBatchKey{channel: n.channelFor(change), botName: n.botName, iconEmoji: change.iconEmoji}
+		if utf8.RuneCountInString(change.botName) > 0 {
+			key.botName = change.botName
+		}
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], change)
+	}
+
+	var lastErr error
+	for _, key := range keys {
+		params := slack.PostMessageParameters{Username: key.botName, IconEmoji: key.iconEmoji}
+		for _, change := range grouped[key] {
+			params.Attachments = append(params.Attachments, formatSlackMessage(n.botName, change).Attachments...)
+		}
+		if err := n.post(key.channel, params); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (n *slackNotifier) post(channel string, params slack.PostMessageParameters) error {
+	backoff := slackRetryBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if _, _, err = n.api.PostMessage(channel, "", params); err == nil {
+			return nil
+		}
+		if attempt == slackRetryAttempts {
+			return err
+		}
+		wait := backoff
+		var rateLimited *slack.RateLimitedError
+		if errors.As(err, &rateLimited) && rateLimited.RetryAfter > 0 {
+			wait = rateLimited.RetryAfter
+		}
+		slog.Warn("slack post failed", "attempt", attempt, "attempts", slackRetryAttempts, "retry_in", wait.String(), "error", err)
+		time.Sleep(wait)
+		backoff *= 2
+	}
+}
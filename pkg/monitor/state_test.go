@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	changed := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	registry := newStatusRegistry(10)
+	registry.register(CheckConfig{Name: "api"})
+	registry.register(CheckConfig{Name: "new"})
+	registry.recordChange(statusChange{name: "api", from: checkStatusOk, to: checkStatusAlarm, time: changed, detail: "503"})
+	if err := saveState(path, registry.snapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots, err := loadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := snapshots["new"]; ok {
+		t.Error("saved a check that never settled")
+	}
+
+	restored := newStatusRegistry(10)
+	restored.restore(snapshots)
+	restored.register(CheckConfig{Name: "api"})
+	if got := restored.status("api"); got != checkStatusAlarm {
+		t.Errorf("restored status = %s, want alarm", checkStatusToString(got))
+	}
+	history, _ := restored.history("api")
+	if len(history) != 1 || history[0].detail != "503" || !history[0].time.Equal(changed) {
+		t.Errorf("restored history = %+v", history)
+	}
+}
+
+func TestRecordChangeKeepsTransitionTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	changed := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	registry := newStatusRegistry(10)
+	registry.persistTo(path)
+	registry.register(CheckConfig{Name: "api"})
+	registry.recordChange(statusChange{name: "api", from: checkStatusOk, to: checkStatusAlarm, time: changed})
+	registry.recordChange(statusChange{name: "api", from: checkStatusAlarm, to: checkStatusAlarm, time: changed.Add(time.Hour), detail: "escalated"})
+
+	history, _ := registry.history("api")
+	if len(history) != 2 {
+		t.Errorf("history has %d changes, want 2", len(history))
+	}
+	snapshots, err := loadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshots["api"].Changed; !got.Equal(changed) {
+		t.Errorf("saved changed = %s, want %s", got, changed)
+	}
+	for _, state := range registry.states() {
+		if !state.changed.Equal(changed) {
+			t.Errorf("changed = %s, want %s", state.changed, changed)
+		}
+	}
+}
+
+func TestLoadStateMissingFile(t *testing.T) {
+	snapshots, err := loadState(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || snapshots != nil {
+		t.Fatalf("loadState = %v, %v, want nil, nil", snapshots, err)
+	}
+}
+
+func runWithState(t *testing.T, config Config) error {
+	t.Helper()
+	m, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	return m.Run(ctx)
+}
+
+func TestStateOnShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"api":{"status":"ok"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runWithState(t, Config{StateFile: path, StateOnShutdown: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("state was not written on clean shutdown: %s", err)
+	}
+
+	if err := runWithState(t, Config{StateFile: path, StateOnShutdown: true, MetricsAddr: "127.0.0.1:99999"}); err == nil {
+		t.Fatal("Run did not report the metrics listen failure")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("state was written after a failed run: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type statusJSON struct {
+	Name       string     `json:"name"`
+	URL        string     `json:"url"`
+	Status     string     `json:"status"`
+	Changed    *time.Time `json:"changed,omitempty"`
+	Checked    *time.Time `json:"checked,omitempty"`
+	Uptime     *float64   `json:"uptime_24h,omitempty"`
+	Suppressed bool       `json:"suppressed,omitempty"`
+}
+
+func stateToJSON(state checkState, now time.Time) statusJSON {
+	item := statusJSON{
+		Name:       state.name,
+		URL:        state.url,
+		Status:     checkStatusToString(state.status),
+		Suppressed: state.suppressed,
+	}
+	if !state.changed.IsZero() {
+		changed := state.changed
+		item.Changed = &changed
+	}
+	if !state.checked.IsZero() {
+		checked := state.checked
+		item.Checked = &checked
+	}
+	if uptime, ok := state.uptime.ratio(now, 24*time.Hour); ok {
+		item.Uptime = &uptime
+	}
+	return item
+}
+
+func statusHandler(registry *statusRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		states := registry.states()
+		items := make([]statusJSON, 0, len(states))
+		for _, state := range states {
+			items = append(items, stateToJSON(state, now))
+		}
+		writeJSON(w, items)
+	}
+}
+
+var statusPageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"upper": strings.ToUpper,
+	"percent": func(ratio float64) string {
+		return fmt.Sprintf("%.2f", ratio*100)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="30">
+<title>itsalive</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { padding: 0.4em 1em; text-align: left; border-bottom: 1px solid #ddd; }
+.ok { color: #2e7d32; }
+.alarm { color: #c62828; }
+.degraded { color: #ef6c00; }
+.unknown { color: #757575; }
+</style>
+</head>
+<body>
+<h1>itsalive</h1>
+<table>
+<tr><th>Check</th><th>Status</th><th>Last check</th><th>Uptime (24h)</th></tr>
+{{range .}}<tr>
+<td>{{.Name}}{{if .URL}} <small>{{.URL}}</small>{{end}}</td>
+<td class="{{.Status}}">{{upper .Status}}{{if .Suppressed}} <small>suppressed</small>{{end}}</td>
+<td>{{if .Checked}}{{.Checked.Format "2006-01-02 15:04:05"}}{{else}}-{{end}}</td>
+<td>{{if .Uptime}}{{percent .Uptime}}%{{else}}-{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func statusPageHandler(registry *statusRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		now := time.Now()
+		states := registry.states()
+		items := make([]statusJSON, 0, len(states))
+		for _, state := range states {
+			items = append(items, stateToJSON(state, now))
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := statusPageTemplate.Execute(w, items); err != nil {
+			slog.Error("status page failed", "error", err)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"unicode/utf8"
+)
+
+func probeTCP(ctx context.Context, config CheckConfig) probeResult {
+	dialer := &net.Dialer{Timeout: config.HTTPTimeout.Duration}
+	if utf8.RuneCountInString(config.SourceIP) > 0 {
+		dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(config.SourceIP)}
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", config.URL)
+	if err != nil {
+		return probeResult{detail: err.Error(), timeout: isTimeout(err), cause: classifyError(err)}
+	}
+	conn.Close()
+	return probeResult{ok: true}
+}
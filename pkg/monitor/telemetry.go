@@ -0,0 +1,210 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type telemetry struct {
+	tracerProvider  *sdktrace.TracerProvider
+	meterProvider   *sdkmetric.MeterProvider
+	tracer          trace.Tracer
+	checks          metric.Int64Counter
+	failures        metric.Int64Counter
+	latency         metric.Float64Histogram
+	phases          metric.Float64Histogram
+	replicaChecks   metric.Int64Counter
+	replicaFailures metric.Int64Counter
+	replicaLatency  metric.Float64Histogram
+}
+
+func newTelemetry(endpoint string, insecure bool) (*telemetry, error) {
+	ctx := context.Background()
+
+	traceOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	metricOptions := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(endpoint)}
+	if insecure {
+		traceOptions = append(traceOptions, otlptracehttp.WithInsecure())
+		metricOptions = append(metricOptions, otlpmetrichttp.WithInsecure())
+	}
+
+	traceExporter, err := otlptracehttp.New(ctx, traceOptions...)
+	if err != nil {
+		return nil, err
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := resource.NewSchemaless(attribute.String("service.name", "itsalive"))
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(res),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+	meter := meterProvider.Meter("itsalive")
+
+	checks, err := meter.Int64Counter("itsalive.checks", metric.WithDescription("checks performed"))
+	if err != nil {
+		return nil, err
+	}
+	failures, err := meter.Int64Counter("itsalive.failures", metric.WithDescription("failed checks"))
+	if err != nil {
+		return nil, err
+	}
+	latency, err := meter.Float64Histogram(
+		"itsalive.latency",
+		metric.WithDescription("check latency"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	phases, err := meter.Float64Histogram(
+		"itsalive.phase.latency",
+		metric.WithDescription("HTTP request phase latency"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	replicaChecks, err := meter.Int64Counter("itsalive.replica.checks", metric.WithDescription("replica checks performed"))
+	if err != nil {
+		return nil, err
+	}
+	replicaFailures, err := meter.Int64Counter("itsalive.replica.failures", metric.WithDescription("failed replica checks"))
+	if err != nil {
+		return nil, err
+	}
+	replicaLatency, err := meter.Float64Histogram(
+		"itsalive.replica.latency",
+		metric.WithDescription("replica check latency"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &telemetry{
+		tracerProvider:  tracerProvider,
+		meterProvider:   meterProvider,
+		tracer:          tracerProvider.Tracer("itsalive"),
+		checks:          checks,
+		failures:        failures,
+		latency:         latency,
+		phases:          phases,
+		replicaChecks:   replicaChecks,
+		replicaFailures: replicaFailures,
+		replicaLatency:  replicaLatency,
+	}, nil
+}
+
+func checkAttributes(config CheckConfig) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("check.name", config.Name),
+		attribute.String("check.url", redactURL(config.URL)),
+		attribute.String("check.type", config.Type),
+	}
+}
+
+func (t *telemetry) start(ctx context.Context, config CheckConfig, start time.Time) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return t.tracer.Start(
+		ctx,
+		"check "+config.Name,
+		trace.WithTimestamp(start),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(checkAttributes(config)...),
+	)
+}
+
+func (t *telemetry) record(
+	span trace.Span,
+	config CheckConfig,
+	start time.Time,
+	elapsed time.Duration,
+	status checkStatus,
+	result probeResult,
+) {
+	if t == nil {
+		return
+	}
+
+	attrs := checkAttributes(config)
+	span.SetAttributes(
+		attribute.String("check.status", checkStatusToString(status)),
+		attribute.Float64("check.latency", elapsed.Seconds()),
+	)
+	if result.statusCode > 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", result.statusCode))
+	}
+	if len(result.cause) > 0 {
+		span.SetAttributes(attribute.String("check.cause", result.cause))
+	}
+	if !result.ok {
+		span.SetStatus(codes.Error, result.detail)
+	}
+
+	ctx := trace.ContextWithSpan(context.Background(), span)
+	for _, phase := range result.phases {
+		_, phaseSpan := t.tracer.Start(ctx, phase.name, trace.WithTimestamp(phase.start))
+		phaseSpan.End(trace.WithTimestamp(phase.end))
+
+		phaseAttrs := append([]attribute.KeyValue{attribute.String("check.phase", phase.name)}, attrs...)
+		t.phases.Record(ctx, phase.end.Sub(phase.start).Seconds(), metric.WithAttributes(phaseAttrs...))
+	}
+	span.End(trace.WithTimestamp(start.Add(elapsed)))
+
+	t.checks.Add(ctx, 1, metric.WithAttributes(attrs...))
+	if status == checkStatusAlarm {
+		t.failures.Add(ctx, 1, metric.WithAttributes(attrs...))
+	}
+	t.latency.Record(ctx, elapsed.Seconds(), metric.WithAttributes(attrs...))
+}
+
+func (t *telemetry) recordReplicas(config CheckConfig, replicas []replicaResult) {
+	if t == nil {
+		return
+	}
+
+	ctx := context.Background()
+	for _, replica := range replicas {
+		attrs := metric.WithAttributes(
+			attribute.String("check.name", config.Name),
+			attribute.String("check.replica", replica.url),
+			attribute.String("check.type", config.Type),
+		)
+		t.replicaChecks.Add(ctx, 1, attrs)
+		if !replica.ok {
+			t.replicaFailures.Add(ctx, 1, attrs)
+		}
+		t.replicaLatency.Record(ctx, replica.elapsed.Seconds(), attrs)
+	}
+}
+
+func (t *telemetry) shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	if err := t.tracerProvider.Shutdown(ctx); err != nil {
+		return err
+	}
+	return t.meterProvider.Shutdown(ctx)
+}
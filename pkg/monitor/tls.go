@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"time"
+	"unicode/utf8"
+)
+
+func earliestExpiry(certs []*x509.Certificate) time.Time {
+	var expiry time.Time
+	for _, cert := range certs {
+		if expiry.IsZero() || cert.NotAfter.Before(expiry) {
+			expiry = cert.NotAfter
+		}
+	}
+	return expiry
+}
+
+func probeTLS(ctx context.Context, config CheckConfig) probeResult {
+	host, _, err := net.SplitHostPort(config.URL)
+	if err != nil {
+		return probeResult{detail: err.Error()}
+	}
+
+	netDialer := &net.Dialer{Timeout: config.HTTPTimeout.Duration}
+	if utf8.RuneCountInString(config.SourceIP) > 0 {
+		netDialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(config.SourceIP)}
+	}
+	tlsConfig := &tls.Config{}
+	if config.tlsConfig != nil {
+		tlsConfig = config.tlsConfig.Clone()
+	}
+	tlsConfig.ServerName = host
+	dialer := &tls.Dialer{NetDialer: netDialer, Config: tlsConfig}
+
+	ctx, cancel := context.WithTimeout(ctx, config.HTTPTimeout.Duration)
+	defer cancel()
+	conn, err := dialer.DialContext(ctx, "tcp", config.URL)
+	var verifyErr *tls.CertificateVerificationError
+	if errors.As(err, &verifyErr) {
+		return probeResult{
+			detail:     "certificate invalid: " + verifyErr.Err.Error(),
+			cause:      causeTLS,
+			certExpiry: earliestExpiry(verifyErr.UnverifiedCertificates),
+		}
+	}
+	if err != nil {
+		return probeResult{detail: err.Error(), timeout: isTimeout(err), cause: classifyError(err)}
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	return probeResult{ok: true, certExpiry: earliestExpiry(state.PeerCertificates)}
+}
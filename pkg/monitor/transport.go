@@ -0,0 +1,94 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"unicode/utf8"
+)
+
+func validateTransport(config CheckConfig) error {
+	if utf8.RuneCountInString(config.ProxyURL) > 0 {
+		parsed, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid ProxyURL: %s", err.Error())
+		}
+		if utf8.RuneCountInString(parsed.Host) == 0 {
+			return fmt.Errorf("invalid ProxyURL %q", redactURL(config.ProxyURL))
+		}
+	}
+
+	if (utf8.RuneCountInString(config.ClientCert) > 0) != (utf8.RuneCountInString(config.ClientKey) > 0) {
+		return errors.New("ClientCert and ClientKey must be set together")
+	}
+
+	return nil
+}
+
+func newTLSConfig(config CheckConfig) (*tls.Config, error) {
+	insecure := config.InsecureSkipVerify != nil && *config.InsecureSkipVerify
+	if !insecure &&
+		utf8.RuneCountInString(config.CAFile) == 0 &&
+		utf8.RuneCountInString(config.ClientCert) == 0 {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure}
+	if utf8.RuneCountInString(config.CAFile) > 0 {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CAFile %q", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if utf8.RuneCountInString(config.ClientCert) > 0 {
+		cert, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func configureTransport(transport *http.Transport, config CheckConfig) {
+	if utf8.RuneCountInString(config.ProxyURL) > 0 {
+		if proxy, err := url.Parse(config.ProxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(proxy)
+		}
+	}
+	if config.tlsConfig != nil {
+		transport.TLSClientConfig = config.tlsConfig.Clone()
+	}
+}
+
+func applyTransportDefaults(config Config, item *CheckConfig) {
+	if utf8.RuneCountInString(item.ProxyURL) == 0 {
+		item.ProxyURL = config.ProxyURL
+	}
+	if item.InsecureSkipVerify == nil {
+		insecure := config.InsecureSkipVerify
+		item.InsecureSkipVerify = &insecure
+	}
+	if utf8.RuneCountInString(item.CAFile) == 0 {
+		item.CAFile = config.CAFile
+	}
+	if utf8.RuneCountInString(item.ClientCert) == 0 && utf8.RuneCountInString(item.ClientKey) == 0 {
+		item.ClientCert = config.ClientCert
+		item.ClientKey = config.ClientKey
+	}
+	if config.FollowRedirects && !item.FollowRedirects {
+		item.FollowRedirects = true
+		if item.MaxRedirects == 0 {
+			item.MaxRedirects = config.MaxRedirects
+		}
+	}
+}
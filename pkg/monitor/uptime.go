@@ -0,0 +1,87 @@
+package monitor
+
+import (
+	"time"
+)
+
+const uptimeBuckets = 30 * 24
+
+var slaWindows = []struct {
+	name   string
+	window time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+type uptimeBucket struct {
+	hour     int64
+	ok       int
+	total    int
+	downtime time.Duration
+}
+
+type uptimeTracker struct {
+	buckets [uptimeBuckets]uptimeBucket
+}
+
+func (t *uptimeTracker) bucket(at time.Time) *uptimeBucket {
+	hour := at.Unix() / 3600
+	bucket := &t.buckets[hour%uptimeBuckets]
+	if bucket.hour != hour {
+		*bucket = uptimeBucket{hour: hour}
+	}
+	return bucket
+}
+
+func (t *uptimeTracker) record(at time.Time, ok bool) {
+	bucket := t.bucket(at)
+	bucket.total++
+	if ok {
+		bucket.ok++
+	}
+}
+
+func (t *uptimeTracker) recordDowntime(end time.Time, downtime time.Duration) {
+	start := end.Add(-downtime)
+	if oldest := end.Truncate(time.Hour).Add(-(uptimeBuckets - 1) * time.Hour); start.Before(oldest) {
+		start = oldest
+	}
+	for start.Before(end) {
+		next := start.Truncate(time.Hour).Add(time.Hour)
+		if next.After(end) {
+			next = end
+		}
+		t.bucket(start).downtime += next.Sub(start)
+		start = next
+	}
+}
+
+func (t *uptimeTracker) ratio(now time.Time, window time.Duration) (float64, bool) {
+	ok, total, _ := t.sum(now, window)
+	if total == 0 {
+		return 0, false
+	}
+	return float64(ok) / float64(total), true
+}
+
+func (t *uptimeTracker) downtime(now time.Time, window time.Duration) time.Duration {
+	_, _, downtime := t.sum(now, window)
+	return downtime
+}
+
+func (t *uptimeTracker) sum(now time.Time, window time.Duration) (int, int, time.Duration) {
+	hour := now.Unix() / 3600
+	hours := int64(window / time.Hour)
+	var ok, total int
+	var downtime time.Duration
+	for _, bucket := range t.buckets {
+		if hour-bucket.hour < hours {
+			ok += bucket.ok
+			total += bucket.total
+			downtime += bucket.downtime
+		}
+	}
+	return ok, total, downtime
+}
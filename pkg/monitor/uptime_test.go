@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUptimeRatio(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+	var tracker uptimeTracker
+	if _, ok := tracker.ratio(now, 24*time.Hour); ok {
+		t.Fatal("empty tracker reported a ratio")
+	}
+
+	for idx := 0; idx < 3; idx++ {
+		tracker.record(now, true)
+	}
+	tracker.record(now, false)
+	tracker.record(now.Add(-3*24*time.Hour), false)
+
+	tests := []struct {
+		window time.Duration
+		want   float64
+	}{
+		{24 * time.Hour, 0.75},
+		{7 * 24 * time.Hour, 0.6},
+		{30 * 24 * time.Hour, 0.6},
+	}
+	for _, tt := range tests {
+		got, ok := tracker.ratio(now, tt.window)
+		if !ok || got != tt.want {
+			t.Errorf("ratio over %s = %v (%v), want %v", tt.window, got, ok, tt.want)
+		}
+	}
+}
+
+func TestUptimeDowntimeSpreadAcrossBuckets(t *testing.T) {
+	end := time.Date(2026, 3, 10, 10, 5, 0, 0, time.UTC)
+	var tracker uptimeTracker
+	tracker.recordDowntime(end, 5*time.Hour)
+
+	tests := []struct {
+		hour int
+		want time.Duration
+	}{
+		{4, 0},
+		{5, 55 * time.Minute},
+		{6, time.Hour},
+		{9, time.Hour},
+		{10, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		at := time.Date(2026, 3, 10, tt.hour, 30, 0, 0, time.UTC)
+		if got := tracker.bucket(at).downtime; got != tt.want {
+			t.Errorf("bucket %02d:00 downtime = %s, want %s", tt.hour, got, tt.want)
+		}
+	}
+
+	if got := tracker.downtime(end, 24*time.Hour); got != 5*time.Hour {
+		t.Errorf("24h downtime = %s, want 5h", got)
+	}
+	if got := tracker.downtime(end.Add(20*time.Hour), 12*time.Hour); got != 0 {
+		t.Errorf("downtime outside the window = %s, want 0s", got)
+	}
+}
+
+func TestUptimeDowntimeLongerThanRetention(t *testing.T) {
+	end := time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC)
+	var tracker uptimeTracker
+	tracker.recordDowntime(end, 60*24*time.Hour)
+
+	if got, want := tracker.downtime(end, 30*24*time.Hour), (uptimeBuckets-1)*time.Hour; got != want {
+		t.Errorf("30d downtime = %s, want %s", got, want)
+	}
+	for _, bucket := range tracker.buckets {
+		if bucket.downtime > time.Hour {
+			t.Fatalf("bucket for hour %d holds %s of downtime", bucket.hour, bucket.downtime)
+		}
+	}
+}
+
+func TestUptimeBucketsWrap(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	var tracker uptimeTracker
+	tracker.record(now.Add(-uptimeBuckets*time.Hour), false)
+	tracker.record(now, true)
+
+	got, ok := tracker.ratio(now, 30*24*time.Hour)
+	if !ok || got != 1 {
+		t.Errorf("ratio = %v (%v), want 1: stale bucket was not reset", got, ok)
+	}
+}
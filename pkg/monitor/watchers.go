@@ -0,0 +1,172 @@
+package monitor
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+type watcherSet struct {
+	sync.Mutex
+	ctx      context.Context
+	registry *statusRegistry
+	tel      *telemetry
+	stats    *metrics
+	events   chan<- statusChange
+	errs     chan<- error
+	slots    chan struct{}
+	cancels  map[string]context.CancelFunc
+	configs  map[string]CheckConfig
+	triggers map[string]chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newWatcherSet(
+	ctx context.Context,
+	registry *statusRegistry,
+	tel *telemetry,
+	stats *metrics,
+	events chan<- statusChange,
+	errs chan<- error,
+	maxConcurrent int,
+) *watcherSet {
+	var slots chan struct{}
+	if maxConcurrent > 0 {
+		slots = make(chan struct{}, maxConcurrent)
+	}
+	return &watcherSet{
+		ctx:      ctx,
+		registry: registry,
+		tel:      tel,
+		stats:    stats,
+		events:   events,
+		errs:     errs,
+		slots:    slots,
+		cancels:  make(map[string]context.CancelFunc),
+		configs:  make(map[string]CheckConfig),
+		triggers: make(map[string]chan struct{}),
+	}
+}
+
+func (w *watcherSet) start(config CheckConfig) bool {
+	w.Lock()
+	defer w.Unlock()
+	return w.startLocked(config)
+}
+
+func (w *watcherSet) startLocked(config CheckConfig) bool {
+	if _, ok := w.cancels[config.Name]; ok || w.ctx.Err() != nil {
+		return false
+	}
+	ctx, cancel := context.WithCancel(w.ctx)
+	w.cancels[config.Name] = cancel
+	w.configs[config.Name] = config
+	trigger := make(chan struct{}, 1)
+	w.triggers[config.Name] = trigger
+	w.registry.register(config)
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		watchURL(ctx, config, w.registry, w.tel, w.stats, w.events, w.slots, trigger, w.errs)
+	}()
+	return true
+}
+
+func (w *watcherSet) restartLocked(config CheckConfig) {
+	w.cancels[config.Name]()
+	w.stats.forget(w.configs[config.Name])
+	delete(w.cancels, config.Name)
+	w.startLocked(config)
+}
+
+func (w *watcherSet) checkNow(name string) bool {
+	w.Lock()
+	defer w.Unlock()
+	trigger, ok := w.triggers[name]
+	if !ok {
+		return false
+	}
+	select {
+	case trigger <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (w *watcherSet) replace(config CheckConfig) bool {
+	w.Lock()
+	defer w.Unlock()
+	if _, ok := w.cancels[config.Name]; !ok || w.ctx.Err() != nil {
+		return false
+	}
+	w.restartLocked(config)
+	return true
+}
+
+func (w *watcherSet) stop(name string) bool {
+	w.Lock()
+	defer w.Unlock()
+	if _, ok := w.cancels[name]; !ok {
+		return false
+	}
+	w.stopLocked(name)
+	return true
+}
+
+func (w *watcherSet) stopLocked(name string) {
+	w.cancels[name]()
+	w.stats.forget(w.configs[name])
+	delete(w.cancels, name)
+	delete(w.configs, name)
+	delete(w.triggers, name)
+	w.registry.unregister(name)
+}
+
+func (w *watcherSet) reconcile(items []CheckConfig) (started, stopped, restarted int) {
+	w.Lock()
+	defer w.Unlock()
+	if w.ctx.Err() != nil {
+		return
+	}
+
+	wanted := make(map[string]bool, len(items))
+	for _, config := range items {
+		wanted[config.Name] = true
+	}
+
+	for name := range w.cancels {
+		if !wanted[name] {
+			w.stopLocked(name)
+			stopped++
+		}
+	}
+
+	for _, config := range items {
+		current, ok := w.configs[config.Name]
+		if !ok {
+			w.startLocked(config)
+			started++
+			continue
+		}
+		if !sameURLConfig(current, config) {
+			w.restartLocked(config)
+			restarted++
+		}
+	}
+	return
+}
+
+func sameURLConfig(a, b CheckConfig) bool {
+	a.bodyTemplate, a.bodyMatch, a.outputMatch = nil, nil, nil
+	b.bodyTemplate, b.bodyMatch, b.outputMatch = nil, nil, nil
+	a.tlsConfig, b.tlsConfig = nil, nil
+	a.messageTemplate, b.messageTemplate = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+func (w *watcherSet) wait() {
+	// Let a start that raced the cancel finish its wg.Add before wg.Wait.
+	w.Lock()
+	w.Unlock()
+	w.wg.Wait()
+}
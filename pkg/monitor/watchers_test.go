@@ -0,0 +1,148 @@
+package monitor
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+)
+
+func execCheck(name string) CheckConfig {
+	return CheckConfig{
+		Name:          name,
+		Type:          checkTypeExec,
+		Command:       "true",
+		CheckInterval: Duration{time.Hour},
+		HTTPTimeout:   Duration{time.Second},
+		OKPeriods:     1,
+		AlarmPeriods:  1,
+	}
+}
+
+func (w *watcherSet) running() []string {
+	w.Lock()
+	defer w.Unlock()
+	names := make([]string, 0, len(w.cancels))
+	for name := range w.cancels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func waitWatchers(t *testing.T, watchers *watcherSet) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		watchers.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a watcher kept running after its check was stopped")
+	}
+}
+
+func TestWatcherSetRefusesRunningName(t *testing.T) {
+	registry := newStatusRegistry(10)
+	events := make(chan statusChange, 100)
+	watchers := newWatcherSet(context.Background(), registry, nil, nil, events, make(chan error, 1), 0)
+
+	if !watchers.start(execCheck("api")) {
+		t.Fatal("first start was refused")
+	}
+	if watchers.start(execCheck("api")) {
+		t.Fatal("started a second watcher for a running check")
+	}
+	started, stopped, restarted := watchers.reconcile([]CheckConfig{execCheck("api"), execCheck("db")})
+	if started != 1 || stopped != 0 || restarted != 0 {
+		t.Fatalf("reconcile = %d started, %d stopped, %d restarted", started, stopped, restarted)
+	}
+
+	changed := execCheck("api")
+	changed.Command = "false"
+	if !watchers.replace(changed) {
+		t.Fatal("replace was refused")
+	}
+	watchers.stop("api")
+	watchers.stop("db")
+	waitWatchers(t, watchers)
+}
+
+func TestStartupRampSkipsChangedChecks(t *testing.T) {
+	m, err := New(Config{
+		StartupRate: 20,
+		Items:       []CheckConfig{execCheck("a"), execCheck("b"), execCheck("c"), execCheck("d")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+
+	for {
+		m.Lock()
+		watchers := m.watchers
+		m.Unlock()
+		if watchers != nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !m.RemoveCheck("d") {
+		t.Fatal("RemoveCheck(d) = false")
+	}
+	if err := m.AddCheck(execCheck("e")); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	got := m.watchers.running()
+	if want := []string{"a", "b", "c", "e"}; len(got) != len(want) || got[0] != "a" || got[3] != "e" {
+		t.Fatalf("running checks = %v, want %v", got, want)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherSetRefusesStartAfterCancel(t *testing.T) {
+	registry := newStatusRegistry(10)
+	events := make(chan statusChange, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	watchers := newWatcherSet(ctx, registry, nil, nil, events, make(chan error, 1), 0)
+
+	cancel()
+	waitWatchers(t, watchers)
+	if watchers.start(execCheck("api")) {
+		t.Fatal("started a watcher after the watcher set was cancelled")
+	}
+	if got := watchers.running(); len(got) != 0 {
+		t.Fatalf("running checks = %v, want none", got)
+	}
+}
+
+func TestStartupRampStopsOnCancel(t *testing.T) {
+	var items []CheckConfig
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		items = append(items, execCheck(name))
+	}
+	m, err := New(Config{StartupRate: 1, Items: items})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := m.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Run took %s to stop during the startup ramp", elapsed)
+	}
+}
@@ -0,0 +1,37 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type webhookNotifier struct {
+	client *http.Client
+	url    string
+}
+
+func newWebhookNotifier(config NotifierConfig) *webhookNotifier {
+	return &webhookNotifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+		url:    config.URL,
+	}
+}
+
+func (n *webhookNotifier) Notify(change statusChange) error {
+	payload, err := json.Marshal(changeToJSON(change))
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer drainBody(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}